@@ -0,0 +1,147 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Offline tracing of the ocerts of a client against an exported ledger
+ * snapshot. The snapshot is a JSON object from ledger keys to base64
+ * encoded values, as written by json.Marshal of a map[string][]byte.
+ *
+ *   go run trace.go -snapshot ledger.json -params sharedParams.json \
+ *       -keypair auditorKeypair.json -delivery-key auditor.pem -id clientID.json
+ *
+ * The shared parameters and the auditor keypair are the results of the
+ * sharedParams and auditorKeypair chaincode functions, the keypair is
+ * opened with the private delivery key of the auditor (PEM, SEC 1 or
+ * PKCS#8), see key_delivery.go. The client id is
+ * an encoded ClientID. Instead of -id, -enrollment gives the enrollment
+ * id of the client. It prints the serials of the ocerts of the client.
+ */
+
+package main
+
+import (
+    "crypto/ecdsa"
+    "crypto/x509"
+    "encoding/pem"
+    "flag"
+    "fmt"
+    "io/ioutil"
+    "math/big"
+    "os"
+    "encoding/json"
+    "ocert"
+)
+
+func readFile(name string) []byte {
+    value, err := ioutil.ReadFile(name)
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+    return value
+}
+
+func readDeliveryKey(name string) *ecdsa.PrivateKey {
+    block, _ := pem.Decode(readFile(name))
+    if block == nil {
+        fmt.Println("Delivery key is not PEM encoded")
+        os.Exit(1)
+    }
+    var key interface{}
+    var err error
+    if block.Type == "EC PRIVATE KEY" {
+        key, err = x509.ParseECPrivateKey(block.Bytes)
+    } else {
+        key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+    }
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+    ecKey, ok := key.(*ecdsa.PrivateKey)
+    if !ok {
+        fmt.Println("Delivery key is not an ECDSA key")
+        os.Exit(1)
+    }
+    return ecKey
+}
+
+func main() {
+    snapshotFile := flag.String("snapshot", "", "exported ledger snapshot")
+    paramsFile := flag.String("params", "", "shared params")
+    keypairFile := flag.String("keypair", "", "sealed auditor keypair")
+    deliveryKeyFile := flag.String("delivery-key", "", "private delivery key of the auditor")
+    idFile := flag.String("id", "", "client id to trace")
+    enrollmentID := flag.String("enrollment", "", "enrollment id to trace")
+    flag.Parse()
+    if *snapshotFile == "" || *paramsFile == "" || *keypairFile == "" || *deliveryKeyFile == "" || (*idFile == "") == (*enrollmentID == "") {
+        flag.Usage()
+        os.Exit(2)
+    }
+
+    snapshot := make(map[string][]byte)
+    err := json.Unmarshal(readFile(*snapshotFile), &snapshot)
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+    sharedParams := new(ocert.SharedParams)
+    err = sharedParams.SetBytes(readFile(*paramsFile))
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+    reply := new(ocert.AuditorKeyReply)
+    err = reply.SetBytes(readFile(*keypairFile))
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+    if reply.Sealed == nil {
+        fmt.Println("The auditor keypair was not delivered")
+        os.Exit(1)
+    }
+    KPa, err := ocert.OpenAuditorKeypair(readDeliveryKey(*deliveryKeyFile), reply.Sealed)
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+    SKa := new(ocert.AuditorSecretKey)
+    SKa.SK = KPa.SK
+    target := new(ocert.ClientID)
+    if *enrollmentID != "" {
+        target = ocert.NewClientID(sharedParams, *enrollmentID)
+    } else {
+        err = target.SetBytes(readFile(*idFile))
+        if err != nil {
+            fmt.Println(err)
+            os.Exit(1)
+        }
+    }
+
+    records, err := ocert.LoadOCertRegistry(snapshot)
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+    serials := ocert.Trace(sharedParams, SKa, target, records)
+    fmt.Printf("[Trace] %d of %d ocerts\n", len(serials), len(records))
+    for _, serial := range serials {
+        fmt.Println(new(big.Int).SetBytes(serial))
+    }
+}
@@ -23,36 +23,48 @@
 package main
 
 import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/x509"
+    "crypto/x509/pkix"
     "fmt"
+    "math/big"
     "ocert"
     "github.com/Nik-U/pbc"
-    "crypto"
-    "crypto/x509"
-    "crypto/sha256"
-    "crypto/rsa"
+    "time"
 )
 
-type DB struct {
-    DB map[string][]byte
-}
-
-func (db *DB) GetState(key string) ([]byte, error) {
-    val, exist := db.DB[key]
-    if exist {
-        return val, nil
-    } else {
-        return nil, fmt.Errorf("Failed to get state")
+// A self-signed enrollment certificate
+func newCreator(commonName string) []byte {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        panic(err.Error())
     }
-}
-
-func (db *DB) PutState(key string, value []byte) error {
-    db.DB[key] = value
-    return nil
+    template := &x509.Certificate{
+        SerialNumber: big.NewInt(1),
+        Subject:      pkix.Name{CommonName: commonName},
+        NotBefore:    time.Now(),
+        NotAfter:     time.Now().Add(24 * time.Hour),
+    }
+    der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+    if err != nil {
+        panic(err.Error())
+    }
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        panic(err.Error())
+    }
+    creator, err := ocert.NewSerializedIdentity("BenchmarkMSP", cert)
+    if err != nil {
+        panic(err.Error())
+    }
+    return creator
 }
 
 func main() {
-    db := new(DB)
-    db.DB = make(map[string][]byte)
+    db := ocert.NewMemoryStub()
+    db.Creator = newCreator("benchmark")
 
     // Benchmark starts here
 
@@ -63,6 +75,7 @@ func main() {
         fmt.Println(err)
         panic(err.Error())
     }
+    db.NextTx()
 
     // Keys
     auditorPKBytesKey := []byte("auditor_pk")
@@ -81,22 +94,21 @@ func main() {
     fmt.Printf("[Benchmark] auditor_pk: ")
     fmt.Println(auditorPK)
 
-    rsaPKBytesKey := []byte("rsa_pk")
-    getArgs = [][]byte{rsaPKBytesKey}
-    rsaPKBytes, err := ocert.Get(db, getArgs)
+    ocertPKBytesKey := []byte("ocert_pk")
+    getArgs = [][]byte{ocertPKBytesKey}
+    ocertPKBytes, err := ocert.Get(db, getArgs)
     if err != nil {
         fmt.Println(err)
         panic(err.Error())
     }
-    rsaPKWrapper := new(ocert.RSAPK)
-    err = rsaPKWrapper.SetBytes(rsaPKBytes)
+    ocertPK := new(ocert.OCertPK)
+    err = ocertPK.SetBytes(ocertPKBytes)
     if err != nil {
         fmt.Println(err)
         panic(err.Error())
     }
-    rsaPK, err := x509.ParsePKIXPublicKey(rsaPKWrapper.PK)
-    fmt.Printf("[Benchmark] rsa_pk: ")
-    fmt.Println(rsaPK)
+    fmt.Printf("[Benchmark] ocert_pk: ")
+    fmt.Println(ocertPK.Algorithm, ocertPK.PK)
 
     sVKBytesKey := []byte("structure_preserving_vk")
     getArgs = [][]byte{sVKBytesKey}
@@ -128,8 +140,12 @@ func main() {
     H := pairing.NewG2().SetBytes(sharedParams.G2)
 
     // GenECert
-    IDc := new(ocert.ClientID)
-    IDc.ID = pairing.NewG1().Rand().Bytes()
+    enrollmentID, err := ocert.EnrollmentIDFromCreator(db.Creator)
+    if err != nil {
+        fmt.Println(err)
+        panic(err.Error())
+    }
+    IDc := ocert.NewClientID(sharedParams, enrollmentID)
     fmt.Printf("[Benchmark] IDc: ")
     fmt.Println(IDc)
 
@@ -140,20 +156,20 @@ func main() {
     fmt.Println(PKc)
 
     ecertRequest := new(ocert.GenECertRequest)
-    ecertRequest.IDc = IDc.ID
     ecertRequest.PKc = PKc.PK
     ecertRequestBytes, err := ecertRequest.Bytes()
     if err != nil {
         fmt.Println(err)
         panic(err.Error())
     }
-    ecertArgs := [][]byte{ecertRequestBytes}
+    db.Transient = ocert.TransientRequest(ecertRequestBytes)
 
-    ecertReplyBytes, err := ocert.GenECert(db, ecertArgs)
+    ecertReplyBytes, err := ocert.GenECert(db, nil)
     if err != nil {
         fmt.Println(err)
         panic(err.Error())
     }
+    db.NextTx()
     ecertReply := new(ocert.GenECertReply)
     err = ecertReply.SetBytes(ecertReplyBytes)
     if err != nil {
@@ -169,6 +185,9 @@ func main() {
     }
     fmt.Printf("[Benchmark] P: ")
     fmt.Println(P)
+    if !ocert.VerifyEncryption(sharedParams, auditorPK, P, IDc, ecertReply.EncProof) {
+        panic("P does not encrypt IDc under auditor_pk")
+    }
 
     ecert := new(ocert.Ecert)
     err = ecert.SetBytes(ecertReply.Ecert)
@@ -185,11 +204,14 @@ func main() {
     fmt.Printf("[Benchmark] newPKc: ")
     fmt.Println(newPKc)
 
-    newP, rprime := ocert.ERerand(sharedParams, auditorPK, P)
+    newP, rprime, rerandProof := ocert.ERerandWithProof(sharedParams, auditorPK, P, nil)
     fmt.Printf("[Benchmark] newP: ")
     fmt.Println(newP)
     fmt.Printf("[Benchmark] rprime: ")
     fmt.Println(rprime)
+    if !ocert.ERerandVerifyPublic(sharedParams, auditorPK, P, newP, rerandProof) {
+        panic("newP is not rerandomized from P")
+    }
 
     // Proof generation
     vars := new(ocert.ProofVariables)
@@ -201,9 +223,11 @@ func main() {
     vars.Xc = Xc
     vars.E = ecert
 
-    pi := ocert.PSetup(sharedParams, vars)
+    pi := ocert.PSetup(sharedParams, vars, nil)
 
     ocertRequest := new(ocert.GenOCertRequest)
+    ocertRequest.Version = ocert.OCertVersion
+    ocertRequest.VKVersion = ecertReply.VKVersion
     ocertRequest.PKc = newPKc.PK
     ocertRequest.P, err = newP.Bytes()
     if err != nil {
@@ -220,13 +244,16 @@ func main() {
         fmt.Println(err)
         panic(err.Error())
     }
-    ocertArgs := [][]byte{ocertRequestBytes}
+    db.Transient = ocert.TransientRequest(ocertRequestBytes)
 
-    ocertReplyBytes, err := ocert.GenOCert(db, ocertArgs)
+    // The enrolled client may not submit its ocert request, a relay does
+    db.Creator = newCreator("relay")
+    ocertReplyBytes, err := ocert.GenOCert(db, nil)
     if err != nil {
         fmt.Println(err)
         panic(err.Error())
     }
+    db.NextTx()
     ocertReply := new(ocert.GenOCertReply)
     err = ocertReply.SetBytes(ocertReplyBytes)
     if err != nil {
@@ -238,17 +265,13 @@ func main() {
     fmt.Println(signature)
 
     // Verify signature
-    msg, err := ocert.OCertSingedBytes(newPKc, newP)
-    if err != nil {
-        fmt.Println(err)
-        panic(err.Error())
-    }
-    hashed := sha256.Sum256(msg)
-    err = rsa.VerifyPKCS1v15(rsaPK.(*rsa.PublicKey), crypto.SHA256, hashed[:], signature)
+    body, err := ocert.VerifyOCert(sharedParams, ocertPK, ocertReply.Body, signature, time.Now())
     if err != nil {
         fmt.Println(err)
         panic(err.Error())
     } else {
         fmt.Println("[Benchmark] ocert verified")
     }
+    fmt.Printf("[Benchmark] ocert body: ")
+    fmt.Println(body)
 }
\ No newline at end of file
@@ -27,13 +27,11 @@ import (
     "fmt"
     "ocert"
     "github.com/Nik-U/pbc"
-    "strings"
+    "encoding/json"
     "time"
-    "crypto"
-    "crypto/x509"
-    "crypto/sha256"
-    "crypto/rsa"
     "os"
+    "strings"
+    "strconv"
 )
 
 func parseOut(out []byte) []byte {
@@ -42,6 +40,30 @@ func parseOut(out []byte) []byte {
     return []byte(str)
 }
 
+/*
+ * The payload of the chaincode response in the output of peer chaincode
+ * invoke, ... result: status:200 payload:"<escaped payload>"
+ */
+func parseInvokeOut(out []byte) []byte {
+    str := string(out)
+    start := strings.Index(str, "payload:\"")
+    if start < 0 {
+        panic("No payload in the invoke output: " + str)
+    }
+    str = str[start + len("payload:"):]
+    if newline := strings.Index(str, "\n"); newline >= 0 {
+        str = str[:newline]
+    }
+    end := strings.LastIndex(str, "\"")
+    // Go does not unescape \' in double quoted strings
+    payload, err := strconv.Unquote(strings.Replace(str[:end + 1], "\\'", "'", -1))
+    if err != nil {
+        fmt.Println(err)
+        panic(err.Error())
+    }
+    return []byte(payload)
+}
+
 func setup() {
     installCmd := "peer chaincode install -p chaincodedev/chaincode/ocert -n mycc -v 0"
     _, err := exec.Command("sh","-c", installCmd).Output()
@@ -50,7 +72,9 @@ func setup() {
         panic(err.Error())
     }
 
-    instantiateCmd := "peer chaincode instantiate -n mycc -v 0 -c '{\"Args\":[]}' -C myc"
+    // Setup writes to the private data collections
+    instantiateCmd := "peer chaincode instantiate -n mycc -v 0 -c '{\"Args\":[]}' -C myc " +
+                      "--collections-config $GOPATH/src/chaincodedev/chaincode/ocert/collections_config.json"
     _, err = exec.Command("sh","-c", instantiateCmd).Output()
     if err != nil {
         fmt.Println(err)
@@ -118,8 +142,8 @@ func sharedParams() *ocert.SharedParams {
     return sharedParams
 }
 
-func rsaPK() (interface{}) {
-    queryCmd := "peer chaincode query -n mycc -c '{\"Args\":[\"get\",\"rsa_pk\"]}' -C myc"
+func ocertPK() (*ocert.OCertPK) {
+    queryCmd := "peer chaincode query -n mycc -c '{\"Args\":[\"get\",\"ocert_pk\"]}' -C myc"
     out, err := exec.Command("sh","-c", queryCmd).Output()
 
     if err != nil {
@@ -127,45 +151,46 @@ func rsaPK() (interface{}) {
         panic(err.Error())
     }
 
-    rsaPK := new(ocert.RSAPK)
-    err = rsaPK.SetBytes(parseOut(out))
+    ocertPK := new(ocert.OCertPK)
+    err = ocertPK.SetBytes(parseOut(out))
 
     if err != nil {
         fmt.Println(err)
         panic(err.Error())
     }
 
-    pk, err := x509.ParsePKIXPublicKey(rsaPK.PK)
-    if err != nil {
-        fmt.Println(err)
-        panic(err.Error())
-    }
-    return pk
+    return ocertPK
 }
 
-func genECert(id *ocert.ClientID, pkc *ocert.ClientPublicKey) (*ocert.Pseudonym, *ocert.Ecert){
+func genECert(sharedParams *ocert.SharedParams,
+              auditorPK *ocert.AuditorPublicKey,
+              pkc *ocert.ClientPublicKey) (*ocert.Pseudonym, *ocert.Ecert){
     request := new(ocert.GenECertRequest)
-    request.IDc = id.ID
     request.PKc = pkc.PK
     requestBytes, err := request.Bytes()
     if err != nil {
         fmt.Println(err)
         panic(err.Error())
     }
-    requestStr := string(requestBytes)
-    requestStr = strings.Replace(requestStr, "\"", "\\\"", -1)
+    // The request is sensitive, it goes in the transient map
+    transient, err := json.Marshal(ocert.TransientRequest(requestBytes))
+    if err != nil {
+        fmt.Println(err)
+        panic(err.Error())
+    }
 
-    queryCmd := "peer chaincode query -n mycc -c '{\"Args\":[\"genECert\" ,\"" +
-                requestStr + "\"]}' -C myc"
+    // Invoke, so the identity directory entry and the event are committed
+    invokeCmd := "peer chaincode invoke -n mycc -c '{\"Args\":[\"genECert\"]}' --transient '" +
+                string(transient) + "' -C myc --waitForEvent"
 
-    out, err := exec.Command("sh","-c", queryCmd).Output()
+    out, err := exec.Command("sh","-c", invokeCmd).CombinedOutput()
 
     if err != nil {
-        fmt.Println(err)
+        fmt.Println(string(out))
         panic(err.Error())
     }
 
-    out = parseOut(out)
+    out = parseInvokeOut(out)
     reply := new(ocert.GenECertReply)
     err = reply.SetBytes(out)
     if err != nil {
@@ -178,6 +203,13 @@ func genECert(id *ocert.ClientID, pkc *ocert.ClientPublicKey) (*ocert.Pseudonym,
         fmt.Println(err)
         panic(err.Error())
     }
+    // The client id is derived from the enrollment certificate of the peer CLI
+    fmt.Printf("[Benchmarkcc] enrollment id: ")
+    fmt.Println(reply.EnrollmentID)
+    id := ocert.NewClientID(sharedParams, reply.EnrollmentID)
+    if !ocert.VerifyEncryption(sharedParams, auditorPK, p, id, reply.EncProof) {
+        panic("P does not encrypt IDc under auditor_pk")
+    }
     ecert := new(ocert.Ecert)
     err = ecert.SetBytes(reply.Ecert)
     if err != nil {
@@ -200,7 +232,7 @@ func genOCert(sharedParams *ocert.SharedParams,
     fmt.Printf("[Benchmarkcc] newPKc: ")
     fmt.Println(newPKc)
 
-    newP, rprime := ocert.ERerand(sharedParams, auditorPK, p)
+    newP, rprime := ocert.ERerand(sharedParams, auditorPK, p, nil)
     fmt.Printf("[Benchmarkcc] newP: ")
     fmt.Println(newP)
     fmt.Printf("[Benchmarkcc] rprime: ")
@@ -211,7 +243,7 @@ func genOCert(sharedParams *ocert.SharedParams,
     // Proof generation
     start := time.Now()
 
-    pi := ocert.PSetup(sharedParams, vars)
+    pi := ocert.PSetup(sharedParams, vars, nil)
     
     end := time.Now()
     elapsed := end.Sub(start)
@@ -244,20 +276,27 @@ func genOCert(sharedParams *ocert.SharedParams,
         fmt.Println(err)
         panic(err.Error())
     }
-    requestStr := string(requestBytes)
-    requestStr = strings.Replace(requestStr, "\"", "\\\"", -1)
+    // The request is sensitive, it goes in the transient map
+    transient, err := json.Marshal(ocert.TransientRequest(requestBytes))
+    if err != nil {
+        fmt.Println(err)
+        panic(err.Error())
+    }
 
-    queryCmd := "peer chaincode query -n mycc -c '{\"Args\":[\"genOCert\" ,\"" +
-                requestStr + "\"]}' -C myc"
+    // Invoke, so the ocert registry entry and the event are committed, as
+    // the relay identity: the client is enrolled by genECert, and its
+    // certificate would be recorded next to the ocert
+    invokeCmd := relayEnv() + " peer chaincode invoke -n mycc -c '{\"Args\":[\"genOCert\"]}' --transient '" +
+                string(transient) + "' -C myc --waitForEvent"
 
-    out, err := exec.Command("sh","-c", queryCmd).Output()
+    out, err := exec.Command("sh","-c", invokeCmd).CombinedOutput()
 
     if err != nil {
-        fmt.Println(err)
+        fmt.Println(string(out))
         panic(err.Error())
     }
 
-    out = parseOut(out)
+    out = parseInvokeOut(out)
     reply := new(ocert.GenOCertReply)
     err = reply.SetBytes(out)
     if err != nil {
@@ -267,6 +306,19 @@ func genOCert(sharedParams *ocert.SharedParams,
     return newPKc, newP, reply.Sig
 }
 
+/*
+ * The peer CLI environment of the identity that submits genOCert, e.g.
+ * CORE_PEER_MSPCONFIGPATH=/etc/hyperledger/relay-msp, or an Idemix MSP
+ * with CORE_PEER_LOCALMSPTYPE=idemix
+ */
+func relayEnv() string {
+    env := os.Getenv("OCERT_RELAY_ENV")
+    if env == "" {
+        panic("OCERT_RELAY_ENV is not set, genOCert cannot be submitted by the enrolled client")
+    }
+    return env
+}
+
 var genOCertLog *os.File
 var genProofLog *os.File
 var genECertLog *os.File
@@ -300,9 +352,9 @@ func main () {
     fmt.Printf("[Benchmarkcc] auditorPK: ")
     fmt.Println(auditorPK)
 
-    rsaPK := rsaPK()
-    fmt.Printf("[Benchmarkcc] rsa_pk: ")
-    fmt.Println(rsaPK)
+    ocertPK := ocertPK()
+    fmt.Printf("[Benchmarkcc] ocert_pk: ")
+    fmt.Println(ocertPK.Algorithm, ocertPK.PK)
 
     sVK := sVK()
     fmt.Printf("[Benchmarkcc] sVK: ")
@@ -320,17 +372,12 @@ func main () {
     for i := 0; i < 100; i++ {
         start := time.Now()
         
-        IDc := new(ocert.ClientID)
-        IDc.ID = pairing.NewG1().Rand().Bytes()
-        fmt.Printf("[Benchmarkcc] IDc: ")
-        fmt.Println(IDc)
-
         PKc := new(ocert.ClientPublicKey)
         Xc := pairing.NewZr().Rand().Bytes()
         PKc.PK = pairing.NewG2().MulZn(H, pairing.NewZr().SetBytes(Xc)).Bytes()
         fmt.Printf("[Benchmarkcc] PKc: ")
         fmt.Println(PKc)
-        P, ecert := genECert(IDc, PKc)
+        P, ecert := genECert(sharedParams, auditorPK, PKc)
         fmt.Printf("[Benchmarkcc] P: ")
         fmt.Println(P)
         fmt.Printf("[Benchmarkcc] ecert: ")
@@ -354,17 +401,12 @@ func main () {
 
 
     // GenECert
-    IDc := new(ocert.ClientID)
-    IDc.ID = pairing.NewG1().Rand().Bytes()
-    fmt.Printf("[Benchmarkcc] IDc: ")
-    fmt.Println(IDc)
-
     PKc := new(ocert.ClientPublicKey)
     Xc := pairing.NewZr().Rand().Bytes()
     PKc.PK = pairing.NewG2().MulZn(H, pairing.NewZr().SetBytes(Xc)).Bytes()
     fmt.Printf("[Benchmarkcc] PKc: ")
     fmt.Println(PKc)
-    P, ecert := genECert(IDc, PKc)
+    P, ecert := genECert(sharedParams, auditorPK, PKc)
     fmt.Printf("[Benchmarkcc] P: ")
     fmt.Println(P)
     fmt.Printf("[Benchmarkcc] ecert: ")
@@ -401,8 +443,7 @@ func main () {
             fmt.Println(err)
             panic(err.Error())
         }
-        hashed := sha256.Sum256(msg)
-        err = rsa.VerifyPKCS1v15(rsaPK.(*rsa.PublicKey), crypto.SHA256, hashed[:], signature)
+        err = ocert.OCertVerifySignature(sharedParams, ocertPK, msg, signature)
         if err != nil {
             fmt.Println(err)
             panic(err.Error())
@@ -25,6 +25,7 @@ package main
 
 import (
     "fmt"
+    "os"
     "ocert"
 
     "github.com/hyperledger/fabric/core/chaincode/shim"
@@ -51,9 +52,21 @@ func (t *OcertAsset) Init(stub shim.ChaincodeStubInterface) peer.Response {
  * ocert chaincode provides the following functions
  *  - genECert
  *  - genOCert
- * and
+ * whose requests, like the ones of reissueECert and rotateAuditorKey, are
+ * passed in the transient map and not in the args, and
  *  - sharedParams
  *  - get
+ *  - advanceEpoch
+ *  - requestDeanonymization
+ *  - approveDeanonymization
+ *  - openDeanonymization
+ *  - traceOCerts
+ *  - rotateAuditorKey
+ *  - reEncryptOCertRegistry
+ *  - reissueECert
+ *  - rotateIssuerKey
+ *  - issuerKeyStatus
+ *  - resolveClientID
  */
 func (t *OcertAsset) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
     // Extract the function and args from the transaction proposal
@@ -81,6 +94,28 @@ func (t *OcertAsset) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
         result, err = ocert.GenECert(stub, args)
     } else if fn == "genOCert" {
         result, err = ocert.GenOCert(stub, args)
+    } else if fn == "advanceEpoch" {
+        result, err = ocert.AdvanceEpoch(stub, args)
+    } else if fn == "requestDeanonymization" {
+        result, err = ocert.RequestDeanonymization(stub, args)
+    } else if fn == "approveDeanonymization" {
+        result, err = ocert.ApproveDeanonymization(stub, args)
+    } else if fn == "openDeanonymization" {
+        result, err = ocert.OpenDeanonymization(stub, args)
+    } else if fn == "traceOCerts" {
+        result, err = ocert.TraceOCerts(stub, args)
+    } else if fn == "rotateAuditorKey" {
+        result, err = ocert.RotateAuditorKey(stub, args)
+    } else if fn == "reEncryptOCertRegistry" {
+        result, err = ocert.ReEncryptOCertRegistry(stub, args)
+    } else if fn == "reissueECert" {
+        result, err = ocert.ReissueECert(stub, args)
+    } else if fn == "rotateIssuerKey" {
+        result, err = ocert.RotateIssuerKey(stub, args)
+    } else if fn == "issuerKeyStatus" {
+        result, err = ocert.GetIssuerKeyStatus(stub, args)
+    } else if fn == "resolveClientID" {
+        result, err = ocert.ResolveClientID(stub, args)
     } else {
         return shim.Error("Unknown functions")
     }
@@ -95,6 +130,13 @@ func (t *OcertAsset) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
 
 // main function starts up the chaincode in the container during instantiate
 func main() {
+    verifyProofLog, err := os.Create("/data/verifyProofLog640.txt")
+    if err != nil {
+        fmt.Printf("Not logging proof verification time: %s\n", err)
+    } else {
+        defer verifyProofLog.Close()
+        ocert.SetVerifyProofLog(verifyProofLog)
+    }
     if err := shim.Start(new(OcertAsset)); err != nil {
         fmt.Printf("Error starting OcertAsset chaincode: %s", err)
     }
@@ -0,0 +1,120 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Auditor secrets in the private data collection of the auditor org. The
+ * auditor keypair, the re-encryption tokens between auditor key versions
+ * and the audit records of decrypted identities are only stored on the
+ * peers of the auditor org, so functions that need them (opening,
+ * tracing, key rotation and ecert re-issuance) must be endorsed by those
+ * peers. Other peers and channel members only see the hashes.
+ */
+
+package ocert
+
+import (
+    "fmt"
+    "strconv"
+)
+
+const AuditorCollection = "auditorCollection"
+const AuditRecordPrefix = "audit_"
+
+func getAuditorKeypair(stub Wrapper) (*AuditorKeypair, error) {
+    value, err := stub.GetPrivateData(AuditorCollection, "auditor_keypair")
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, nil
+    }
+    KPa := new(AuditorKeypair)
+    err = KPa.SetBytes(value)
+    if err != nil {
+        return nil, err
+    }
+    return KPa, nil
+}
+
+func putAuditorKeypair(stub Wrapper, KPa *AuditorKeypair) error {
+    value, err := KPa.Bytes()
+    if err != nil {
+        return err
+    }
+    return stub.PutPrivateData(AuditorCollection, "auditor_keypair", value)
+}
+
+/*
+ * The auditor secret key, there is none for a threshold key
+ */
+func auditorSecretKey(stub Wrapper) (*AuditorSecretKey, error) {
+    KPa, err := getAuditorKeypair(stub)
+    if err != nil {
+        return nil, err
+    }
+    if KPa == nil || KPa.SK == nil {
+        return nil, fmt.Errorf("No auditor secret key")
+    }
+    SKa := new(AuditorSecretKey)
+    SKa.SK = KPa.SK
+    return SKa, nil
+}
+
+/*
+ * The re-encryption token from auditor key version to version + 1
+ */
+func getReKeyToken(stub Wrapper, version int) (*ReKeyToken, error) {
+    key := "auditor_rekey_token_" + strconv.Itoa(version)
+    value, err := stub.GetPrivateData(AuditorCollection, key)
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, fmt.Errorf("Asset not found: %s", key)
+    }
+    token := new(ReKeyToken)
+    err = token.SetBytes(value)
+    if err != nil {
+        return nil, err
+    }
+    return token, nil
+}
+
+func putReKeyToken(stub Wrapper, version int, token *ReKeyToken) error {
+    value, err := token.Bytes()
+    if err != nil {
+        return err
+    }
+    return stub.PutPrivateData(AuditorCollection, "auditor_rekey_token_" + strconv.Itoa(version), value)
+}
+
+/*
+ * Record a decryption of client ids, one per transaction
+ */
+func putAuditRecord(stub Wrapper, record *AuditRecord) error {
+    now, err := txTime(stub)
+    if err != nil {
+        return err
+    }
+    record.Time = now
+    value, err := record.Bytes()
+    if err != nil {
+        return err
+    }
+    return stub.PutPrivateData(AuditorCollection, AuditRecordPrefix + stub.GetTxID(), value)
+}
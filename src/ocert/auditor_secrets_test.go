@@ -0,0 +1,117 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "bytes"
+    "encoding/base64"
+    "testing"
+)
+
+/*
+ * The auditor keypair is only in the auditor collection, and openings and
+ * traces are recorded there. A peer that is not a member of the collection
+ * cannot open or trace.
+ */
+func TestAuditorCollection(t *testing.T) {
+    stub := NewMemoryStub()
+    requester := []byte("requester")
+    approver := []byte("approver")
+    policy := new(DeanonPolicy)
+    policy.Required = 1
+    policy.Approvers = []string{IdentityID(approver)}
+    policyBytes, err := policy.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    key, deliveryKey := newTestDeliveryKey()
+    replyBytes, err := Setup(stub, [][]byte{[]byte(""), nil, policyBytes, deliveryKey})
+    if err != nil {
+        t.Fatal(err)
+    }
+    KPa, err := openTestKeypair(key, replyBytes)
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    stored, err := getAuditorKeypair(stub)
+    if err != nil || stored == nil || !bytes.Equal(stored.SK, KPa.SK) {
+        t.Fatalf("keypair in the collection differs: %v", err)
+    }
+    SKb64 := []byte(base64.StdEncoding.EncodeToString(KPa.SK))
+    for k, value := range stub.State {
+        if bytes.Contains(value, SKb64) {
+            t.Errorf("secret key in public state %q", k)
+        }
+    }
+
+    // Open a pseudonym
+    PKa := new(AuditorPublicKey)
+    PKa.PK = KPa.PK
+    id := NewClientID(sharedParams, "Org1MSP::CN=alice")
+    PBytes, err := EEnc(sharedParams, PKa, id, nil).Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    request := &DeanonRequest{P: PBytes, Reason: "court order", DeliveryKey: deliveryKey}
+    requestBytes, err := request.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Creator = requester
+    if _, err := RequestDeanonymization(stub, [][]byte{requestBytes}); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    stub.Creator = approver
+    if _, err := ApproveDeanonymization(stub, [][]byte{[]byte("1")}); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+
+    stub.Creator = requester
+    stub.NotMember[AuditorCollection] = true
+    if _, err := OpenDeanonymization(stub, [][]byte{[]byte("1")}); err == nil {
+        t.Error("opens on a peer that is not a member of the collection")
+    }
+    stub.NotMember[AuditorCollection] = false
+    if _, err := invokeTx(t, stub, 1, OpenDeanonymization, [][]byte{[]byte("1")}); err != nil {
+        t.Fatal(err)
+    }
+    audit := new(AuditRecord)
+    err = audit.SetBytes(stub.Private[AuditorCollection][AuditRecordPrefix + stub.TxID])
+    if err != nil || audit.Kind != "open" || audit.Request != 1 || !bytes.Equal(audit.ClientID, id.ID) {
+        t.Errorf("open audit record %v: %v", audit, err)
+    }
+
+    // Trace
+    idBytes, err := id.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Creator = approver
+    stub.NextTx()
+    if _, err := TraceOCerts(stub, [][]byte{idBytes}); err != nil {
+        t.Fatal(err)
+    }
+    audit = new(AuditRecord)
+    err = audit.SetBytes(stub.Private[AuditorCollection][AuditRecordPrefix + stub.TxID])
+    if err != nil || audit.Kind != "trace" || !bytes.Equal(audit.ClientID, id.ID) {
+        t.Errorf("trace audit record %v: %v", audit, err)
+    }
+}
@@ -17,7 +17,7 @@
  */
 
 /*
- * Used to generate ecert, the X.509 certificate that contains the 
+ * Used to generate ocert, the X.509 certificate that contains the 
  * signature on client public key and pseudonym
  */
 
@@ -25,6 +25,94 @@ package ocert
 
 import (
     "fmt"
+    cryptorand "crypto/rand"
+    "crypto/sha256"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/asn1"
+    "encoding/hex"
+    "io"
+    "math/big"
+    "time"
+)
+
+/*
+ * Version of OCertBody issued by GenOCert
+ */
+const OCertVersion = 1
+
+/*
+ * Version of GenOCertRequest replied with an X.509 ocert
+ */
+const OCertVersionX509 = 2
+
+/*
+ * Object identifiers used in X.509 ocerts. They are under the IANA
+ * experimental arc 1.3.6.1.3 and should move to a registered arc before
+ * ocerts are used outside of this project.
+ *  - OIDPairingG2PublicKey is the algorithm of the subject public key, which
+ *    is the client public key PKc in G2. The subject public key is the
+ *    element encoded by pbc Element.Bytes().
+ *  - OIDPseudonymExtension is the non-critical extension holding the
+ *    pseudonym P' of the client, DER encoded as x509Pseudonym.
+ */
+var (
+    OIDPairingG2PublicKey = asn1.ObjectIdentifier{1, 3, 6, 1, 3, 2017, 1, 1}
+    OIDPseudonymExtension = asn1.ObjectIdentifier{1, 3, 6, 1, 3, 2017, 1, 2}
+)
+
+/*
+ * Pseudonym ::= SEQUENCE {
+ *     c OCTET STRING,
+ *     d OCTET STRING }
+ */
+type x509Pseudonym struct {
+    C []byte
+    D []byte
+}
+
+/*
+ * The ASN.1 structures of an X.509 certificate (RFC 5280). crypto/x509 can
+ * only create certificates for the public key types it knows, so the ocert
+ * is assembled here and parsed by crypto/x509.
+ */
+type x509Validity struct {
+    NotBefore time.Time
+    NotAfter  time.Time
+}
+
+type x509PublicKeyInfo struct {
+    Algorithm pkix.AlgorithmIdentifier
+    PublicKey asn1.BitString
+}
+
+type x509TBSCertificate struct {
+    Version            int `asn1:"optional,explicit,default:0,tag:0"`
+    SerialNumber       *big.Int
+    SignatureAlgorithm pkix.AlgorithmIdentifier
+    Issuer             asn1.RawValue
+    Validity           x509Validity
+    Subject            asn1.RawValue
+    PublicKey          x509PublicKeyInfo
+    Extensions         []pkix.Extension `asn1:"optional,explicit,tag:3"`
+}
+
+type x509Certificate struct {
+    TBSCertificate     asn1.RawValue
+    SignatureAlgorithm pkix.AlgorithmIdentifier
+    SignatureValue     asn1.BitString
+}
+
+/*
+ * An ocert is valid for OCertLifetime after the transaction that issued it
+ */
+const OCertLifetime = 24 * time.Hour
+
+/*
+ * Key usages of the client public key in an ocert
+ */
+const (
+    OCertKeyUsageDigitalSignature = "digitalSignature"
 )
 
 /*
@@ -39,4 +127,224 @@ func OCertSingedBytes(PKc *ClientPublicKey, P *Pseudonym) ([]byte, error) {
     }
     result = append(result[:], Pbytes[:]...)
     return result, nil
-}
\ No newline at end of file
+}
+
+/*
+ * The issuer ID is the hex encoded SHA-256 of the encoded issuer public
+ * key
+ */
+func OCertIssuerID(publicKeyBytes []byte) string {
+    hashed := sha256.Sum256(publicKeyBytes)
+    return hex.EncodeToString(hashed[:])
+}
+
+/*
+ * The serial of the ocert issued by transaction txID, the first 127 bits
+ * of the SHA-256 of txID with the top one set, so every endorser derives
+ * the same positive serial of 16 bytes. A transaction issues at most one
+ * ocert and transaction IDs are unique, so the serials are too.
+ */
+func OCertSerial(txID string) *big.Int {
+    hashed := sha256.Sum256([]byte(txID))
+    serial := new(big.Int).SetBytes(hashed[:16])
+    serial.Rsh(serial, 1)
+    return serial.SetBit(serial, 126, 1)
+}
+
+/*
+ * Build the body of an ocert issued at time now
+ */
+func NewOCertBody(PKc *ClientPublicKey, P *Pseudonym, serial *big.Int, issuer string, now time.Time) (*OCertBody, error) {
+    Pbytes, err := P.Bytes()
+    if err != nil {
+        return nil, err
+    }
+
+    body := new(OCertBody)
+    body.Version = OCertVersion
+    body.Serial = serial.Bytes()
+    body.Issuer = issuer
+    body.NotBefore = now.Unix()
+    body.NotAfter = now.Add(OCertLifetime).Unix()
+    body.KeyUsage = []string{OCertKeyUsageDigitalSignature}
+    body.PKc = PKc.PK
+    body.P = Pbytes
+    return body, nil
+}
+
+/*
+ * Verify the signature on an ocert body and that now is in the validity
+ * window of the ocert, and return the parsed body
+ */
+func VerifyOCert(sharedParams *SharedParams, pk *OCertPK, bodyBytes []byte, sig []byte, now time.Time) (*OCertBody, error) {
+    err := OCertVerifySignature(sharedParams, pk, bodyBytes, sig)
+    if err != nil {
+        return nil, fmt.Errorf("Invalid ocert signature: %s", err)
+    }
+
+    body := new(OCertBody)
+    err = body.SetBytes(bodyBytes)
+    if err != nil {
+        return nil, err
+    }
+    if body.Version != OCertVersion {
+        return nil, fmt.Errorf("Unsupported ocert version: %d", body.Version)
+    }
+    if now.Unix() < body.NotBefore {
+        return nil, fmt.Errorf("Ocert is not valid before %s", time.Unix(body.NotBefore, 0))
+    }
+    if now.Unix() > body.NotAfter {
+        return nil, fmt.Errorf("Ocert expired at %s", time.Unix(body.NotAfter, 0))
+    }
+    return body, nil
+}
+
+/*
+ * The X.509 signature algorithm of an ocert signer
+ */
+func x509SignatureAlgorithm(algorithm string) (x509.SignatureAlgorithm, error) {
+    switch algorithm {
+    case "", OCertSignerRSAPKCS1:
+        return x509.SHA256WithRSA, nil
+    case OCertSignerRSAPSS:
+        return x509.SHA256WithRSAPSS, nil
+    case OCertSignerECDSA:
+        return x509.ECDSAWithSHA256, nil
+    case OCertSignerEd25519:
+        return x509.PureEd25519, nil
+    }
+    return x509.UnknownSignatureAlgorithm, fmt.Errorf("X.509 ocerts are not supported by %s", algorithm)
+}
+
+/*
+ * Create the self-signed certificate of the ocert issuer, so ocerts can be
+ * chained to it by existing PKI tooling
+ */
+func NewIssuerCertificate(signer OCertSigner, now time.Time, rand io.Reader) ([]byte, error) {
+    signatureAlgorithm, err := x509SignatureAlgorithm(signer.Algorithm())
+    if err != nil {
+        return nil, err
+    }
+    key := signer.CryptoSigner()
+    template := &x509.Certificate{
+        SerialNumber:          big.NewInt(0),
+        Subject:               pkix.Name{CommonName: "ocert issuer"},
+        NotBefore:             now,
+        NotAfter:              now.AddDate(10, 0, 0),
+        KeyUsage:              x509.KeyUsageCertSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+        SignatureAlgorithm:    signatureAlgorithm,
+    }
+    if rand == nil {
+        rand = cryptorand.Reader
+    }
+    return x509.CreateCertificate(rand, template, template, key.Public(), key)
+}
+
+/*
+ * Create an X.509 ocert issued at time now, with the client public key as
+ * subject public key and the pseudonym in OIDPseudonymExtension. The ocert
+ * is signed by the issuer key with the signature algorithm of the issuer
+ * certificate.
+ */
+func NewX509OCert(issuer *x509.Certificate, signer OCertSigner, PKc *ClientPublicKey, P *Pseudonym, serial *big.Int, now time.Time, rand io.Reader) ([]byte, error) {
+    subject, err := asn1.Marshal(pkix.Name{CommonName: "ocert"}.ToRDNSequence())
+    if err != nil {
+        return nil, err
+    }
+    pseudonym, err := asn1.Marshal(x509Pseudonym{P.C, P.D})
+    if err != nil {
+        return nil, err
+    }
+    keyUsage, err := asn1.Marshal(asn1.BitString{Bytes: []byte{0x80}, BitLength: 1})
+    if err != nil {
+        return nil, err
+    }
+
+    // The issuer certificate is self-signed by the same key, so it has the
+    // algorithm identifier (and parameters) to use
+    issuerCert := new(x509Certificate)
+    _, err = asn1.Unmarshal(issuer.Raw, issuerCert)
+    if err != nil {
+        return nil, err
+    }
+    signatureAlgorithm := issuerCert.SignatureAlgorithm
+
+    tbs := x509TBSCertificate{
+        Version:            2,
+        SerialNumber:       serial,
+        SignatureAlgorithm: signatureAlgorithm,
+        Issuer:             asn1.RawValue{FullBytes: issuer.RawSubject},
+        Validity:           x509Validity{now.UTC(), now.Add(OCertLifetime).UTC()},
+        Subject:            asn1.RawValue{FullBytes: subject},
+        PublicKey:          x509PublicKeyInfo{
+            Algorithm: pkix.AlgorithmIdentifier{Algorithm: OIDPairingG2PublicKey},
+            PublicKey: asn1.BitString{Bytes: PKc.PK, BitLength: 8 * len(PKc.PK)},
+        },
+        Extensions:         []pkix.Extension{
+            // Key usage: digitalSignature
+            pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 15}, Critical: true, Value: keyUsage},
+            pkix.Extension{Id: OIDPseudonymExtension, Value: pseudonym},
+        },
+    }
+    tbsBytes, err := asn1.Marshal(tbs)
+    if err != nil {
+        return nil, err
+    }
+
+    signature, err := signer.Sign(tbsBytes, rand)
+    if err != nil {
+        return nil, err
+    }
+
+    return asn1.Marshal(x509Certificate{
+        asn1.RawValue{FullBytes: tbsBytes},
+        signatureAlgorithm,
+        asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)},
+    })
+}
+
+/*
+ * Verify an X.509 ocert against the issuer certificate at time now, and
+ * return the client public key and the pseudonym in the ocert
+ */
+func ParseX509OCert(der []byte, issuer *x509.Certificate, now time.Time) (*ClientPublicKey, *Pseudonym, *x509.Certificate, error) {
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    roots := x509.NewCertPool()
+    roots.AddCert(issuer)
+    _, err = cert.Verify(x509.VerifyOptions{Roots: roots, CurrentTime: now})
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    spki := new(x509PublicKeyInfo)
+    _, err = asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, spki)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+    if !spki.Algorithm.Algorithm.Equal(OIDPairingG2PublicKey) {
+        return nil, nil, nil, fmt.Errorf("Ocert public key is not in G2")
+    }
+    PKc := new(ClientPublicKey)
+    PKc.PK = spki.PublicKey.RightAlign()
+
+    for _, ext := range cert.Extensions {
+        if ext.Id.Equal(OIDPseudonymExtension) {
+            pseudonym := new(x509Pseudonym)
+            _, err = asn1.Unmarshal(ext.Value, pseudonym)
+            if err != nil {
+                return nil, nil, nil, err
+            }
+            P := new(Pseudonym)
+            P.C = pseudonym.C
+            P.D = pseudonym.D
+            return PKc, P, cert, nil
+        }
+    }
+    return nil, nil, nil, fmt.Errorf("Ocert has no pseudonym")
+}
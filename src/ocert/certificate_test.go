@@ -0,0 +1,251 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "testing"
+    "crypto/x509"
+    "math/big"
+    "bytes"
+    "time"
+)
+
+/*
+ * Serials are the same for a transaction ID, positive, of 16 bytes and
+ * differ between transactions
+ */
+func TestOCertSerial(t *testing.T) {
+    serial := OCertSerial("tx1")
+    if serial.Cmp(OCertSerial("tx1")) != 0 {
+        t.Error("different serials for the same transaction")
+    }
+    if serial.Sign() <= 0 || len(serial.Bytes()) != 16 {
+        t.Errorf("serial %v", serial)
+    }
+    if serial.Cmp(OCertSerial("tx2")) == 0 {
+        t.Error("same serial for different transactions")
+    }
+}
+
+/*
+ * Sign a message with each ocert signature algorithm, verify it with the
+ * public key stored on the ledger and reject a modified message
+ */
+func TestOCertSigners(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    algorithms := []string{OCertSignerRSAPKCS1, OCertSignerRSAPSS,
+        OCertSignerECDSA, OCertSignerEd25519, OCertSignerBLS}
+
+    for _, algorithm := range algorithms {
+        t.Run(algorithm, func(t *testing.T) {
+            signer, err := NewOCertSigner(sharedParams, algorithm, nil)
+            if err != nil {
+                t.Fatal(err)
+            }
+            pk, err := signer.PublicKey()
+            if err != nil {
+                t.Fatal(err)
+            }
+            pkBytes, err := pk.Bytes()
+            if err != nil {
+                t.Fatal(err)
+            }
+            ledgerPK := new(OCertPK)
+            if err := ledgerPK.SetBytes(pkBytes); err != nil {
+                t.Fatal(err)
+            }
+
+            msg := []byte("ocert")
+            sig, err := signer.Sign(msg, nil)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if err := OCertVerifySignature(sharedParams, ledgerPK, msg, sig); err != nil {
+                t.Errorf("verify: %v", err)
+            }
+            if OCertVerifySignature(sharedParams, ledgerPK, []byte("ocerts"), sig) == nil {
+                t.Error("accepts a signature on a modified message")
+            }
+        })
+    }
+}
+
+/*
+ * Every endorser derives the same key from the same stream and signs the
+ * same message alike, and the key survives the issuer collection
+ */
+func TestOCertSignerSeed(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    seed := []byte("0123456789abcdef0123456789abcdef")
+    algorithms := []string{OCertSignerRSAPKCS1, OCertSignerRSAPSS,
+        OCertSignerECDSA, OCertSignerEd25519, OCertSignerBLS}
+
+    for _, algorithm := range algorithms {
+        t.Run(algorithm, func(t *testing.T) {
+            signer, err := NewOCertSigner(sharedParams, algorithm, NewPRFReader(seed, []byte(algorithm)))
+            if err != nil {
+                t.Fatal(err)
+            }
+            value, err := signer.PrivateKey()
+            if err != nil {
+                t.Fatal(err)
+            }
+            other, err := NewOCertSigner(sharedParams, algorithm, NewPRFReader(seed, []byte(algorithm)))
+            if err != nil {
+                t.Fatal(err)
+            }
+            otherValue, err := other.PrivateKey()
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !bytes.Equal(value, otherValue) {
+                t.Error("derives another key from the same stream")
+            }
+            parsed, err := ParseOCertSigner(sharedParams, algorithm, value)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            msg := []byte("ocert")
+            sig, err := signer.Sign(msg, NewPRFReader(seed, []byte("signature")))
+            if err != nil {
+                t.Fatal(err)
+            }
+            parsedSig, err := parsed.Sign(msg, NewPRFReader(seed, []byte("signature")))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !bytes.Equal(sig, parsedSig) {
+                t.Error("signs the same message differently")
+            }
+        })
+    }
+}
+
+/*
+ * Sign an ocert body and verify it inside and outside of its validity
+ * window
+ */
+func TestOCertValidity(t *testing.T) {
+    signer, err := NewOCertSigner(nil, OCertSignerRSAPKCS1, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    pk, err := signer.PublicKey()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    PKc := new(ClientPublicKey)
+    PKc.PK = []byte("PKc")
+    P := new(Pseudonym)
+    P.C = []byte("C")
+    P.D = []byte("D")
+
+    issued := time.Unix(1500000000, 0)
+    body, err := NewOCertBody(PKc, P, big.NewInt(7), "issuer", issued)
+    if err != nil {
+        t.Fatal(err)
+    }
+    bodyBytes, err := body.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    sig, err := signer.Sign(bodyBytes, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    parsed, err := VerifyOCert(nil, pk, bodyBytes, sig, issued.Add(time.Hour))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if parsed.Issuer != "issuer" || big.NewInt(0).SetBytes(parsed.Serial).Int64() != 7 {
+        t.Errorf("parsed body %v", parsed)
+    }
+
+    outside := []struct {
+        name string
+        now time.Time
+    }{
+        {"BeforeWindow", issued.Add(-time.Hour)},
+        {"AfterWindow", issued.Add(OCertLifetime + time.Hour)},
+    }
+    for _, tc := range outside {
+        if _, err := VerifyOCert(nil, pk, bodyBytes, sig, tc.now); err == nil {
+            t.Errorf("%s: accepts an ocert outside of its validity window", tc.name)
+        }
+    }
+
+    bodyBytes[len(bodyBytes) - 2] ^= 1
+    if _, err := VerifyOCert(nil, pk, bodyBytes, sig, issued.Add(time.Hour)); err == nil {
+        t.Error("accepts a modified body")
+    }
+}
+
+/*
+ * Issue an X.509 ocert with each algorithm that supports X.509, verify it
+ * against the issuer certificate and recover the client public key and
+ * pseudonym
+ */
+func TestX509OCert(t *testing.T) {
+    algorithms := []string{OCertSignerRSAPKCS1, OCertSignerRSAPSS,
+        OCertSignerECDSA, OCertSignerEd25519}
+
+    for _, algorithm := range algorithms {
+        t.Run(algorithm, func(t *testing.T) {
+            signer, err := NewOCertSigner(nil, algorithm, nil)
+            if err != nil {
+                t.Fatal(err)
+            }
+            issued := time.Unix(1500000000, 0)
+            issuerBytes, err := NewIssuerCertificate(signer, issued, nil)
+            if err != nil {
+                t.Fatal(err)
+            }
+            issuer, err := x509.ParseCertificate(issuerBytes)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            PKc := new(ClientPublicKey)
+            PKc.PK = []byte("PKc")
+            P := new(Pseudonym)
+            P.C = []byte("C")
+            P.D = []byte("D")
+
+            der, err := NewX509OCert(issuer, signer, PKc, P, big.NewInt(7), issued, nil)
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            PKc2, P2, cert, err := ParseX509OCert(der, issuer, issued.Add(time.Hour))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !bytes.Equal(PKc.PK, PKc2.PK) || !P.Equals(P2) || cert.SerialNumber.Int64() != 7 {
+                t.Errorf("parsed serial %v with a different key or pseudonym", cert.SerialNumber)
+            }
+
+            _, _, _, err = ParseX509OCert(der, issuer, issued.Add(OCertLifetime + time.Hour))
+            if err == nil {
+                t.Error("accepts an ocert after its validity window")
+            }
+        })
+    }
+}
@@ -0,0 +1,447 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * De-anonymization is a governed action. A pseudonym is only opened after
+ * a request for it has been filed on the ledger and approved by enough of
+ * the designated approvers. Every step is recorded in the request on the
+ * ledger, so the history of a request is the audit trail of the use of
+ * the auditor key. It contains the following chaincode functions
+ *  - RequestDeanonymization
+ *  - ApproveDeanonymization
+ *  - OpenDeanonymization
+ */
+
+package ocert
+
+import (
+    "fmt"
+    "crypto/sha256"
+    "crypto/x509"
+    "encoding/hex"
+    "strconv"
+    "time"
+)
+
+const (
+    DeanonPending  = "pending"
+    DeanonApproved = "approved"
+    DeanonOpened   = "opened"
+)
+
+/*
+ * The ID of a caller identity, the hex encoded SHA-256 of the serialized
+ * identity returned by GetCreator
+ */
+func IdentityID(creator []byte) string {
+    hash := sha256.Sum256(creator)
+    return hex.EncodeToString(hash[:])
+}
+
+func callerID(stub Wrapper) (string, error) {
+    creator, err := stub.GetCreator()
+    if err != nil {
+        return "", err
+    }
+    if len(creator) == 0 {
+        return "", fmt.Errorf("No caller identity")
+    }
+    return IdentityID(creator), nil
+}
+
+func txTime(stub Wrapper) (int64, error) {
+    ts, err := stub.GetTxTimestamp()
+    if err != nil {
+        return 0, err
+    }
+    return ts.Seconds, nil
+}
+
+/*
+ * Check that the caller is one of the approvers of the policy and return
+ * its identity ID
+ */
+func requireApprover(stub Wrapper, policy *DeanonPolicy) (string, error) {
+    caller, err := callerID(stub)
+    if err != nil {
+        return "", err
+    }
+    for _, approver := range policy.Approvers {
+        if approver == caller {
+            return caller, nil
+        }
+    }
+    return "", fmt.Errorf("%s is not a de-anonymization approver", caller)
+}
+
+func getDeanonPolicy(stub Wrapper) (*DeanonPolicy, error) {
+    value, err := stub.GetState("deanon_policy")
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, fmt.Errorf("No de-anonymization policy")
+    }
+    policy := new(DeanonPolicy)
+    err = policy.SetBytes(value)
+    if err != nil {
+        return nil, err
+    }
+    return policy, nil
+}
+
+/*
+ * Check and store the de-anonymization policy, called by Setup
+ */
+func putDeanonPolicy(stub Wrapper, policy *DeanonPolicy) error {
+    if policy.Required < 1 || policy.Required > len(policy.Approvers) {
+        return fmt.Errorf("Invalid de-anonymization policy: %d of %d approvals",
+            policy.Required, len(policy.Approvers))
+    }
+    approvers := make(map[string]bool)
+    for _, approver := range policy.Approvers {
+        if approvers[approver] {
+            return fmt.Errorf("Duplicate approver: %s", approver)
+        }
+        approvers[approver] = true
+    }
+    value, err := policy.Bytes()
+    if err != nil {
+        return err
+    }
+    return stub.PutState("deanon_policy", value)
+}
+
+/*
+ * Increment the counter stored on the ledger under key and return its
+ * new value, the first value is 1
+ */
+func nextCount(stub Wrapper, key string) (uint64, error) {
+    value, err := stub.GetState(key)
+    if err != nil {
+        return 0, err
+    }
+    count := uint64(0)
+    if value != nil {
+        count, err = strconv.ParseUint(string(value), 10, 64)
+        if err != nil {
+            return 0, err
+        }
+    }
+    count++
+    err = stub.PutState(key, []byte(strconv.FormatUint(count, 10)))
+    if err != nil {
+        return 0, err
+    }
+    return count, nil
+}
+
+func deanonRecordKey(id uint64) string {
+    return "deanon_request_" + strconv.FormatUint(id, 10)
+}
+
+func getDeanonRecord(stub Wrapper, arg []byte) (*DeanonRecord, error) {
+    id, err := strconv.ParseUint(string(arg), 10, 64)
+    if err != nil {
+        return nil, fmt.Errorf("Invalid de-anonymization request id: %s", arg)
+    }
+    value, err := stub.GetState(deanonRecordKey(id))
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, fmt.Errorf("De-anonymization request not found: %d", id)
+    }
+    record := new(DeanonRecord)
+    err = record.SetBytes(value)
+    if err != nil {
+        return nil, err
+    }
+    return record, nil
+}
+
+/*
+ * Append a step to the audit trail of the request and write the request
+ * to the ledger
+ */
+func putDeanonRecord(stub Wrapper, record *DeanonRecord, action string, identity string) ([]byte, error) {
+    now, err := txTime(stub)
+    if err != nil {
+        return nil, err
+    }
+    record.Log = append(record.Log, DeanonLogEntry{action, identity, now})
+    value, err := record.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    err = stub.PutState(deanonRecordKey(record.ID), value)
+    if err != nil {
+        return nil, err
+    }
+    return value, nil
+}
+
+/*
+ * The pseudonym in an ocert issued by GenOCert, and its serial number.
+ * The ocert is verified, but may have expired.
+ */
+func ocertPseudonym(stub Wrapper, reply *GenOCertReply) (*Pseudonym, []byte, error) {
+    if reply.Cert != nil {
+        issuerCertificate, err := getIssuerCertificate(stub)
+        if err != nil {
+            return nil, nil, err
+        }
+        if issuerCertificate == nil {
+            return nil, nil, fmt.Errorf("No issuer certificate")
+        }
+        cert, err := x509.ParseCertificate(reply.Cert)
+        if err != nil {
+            return nil, nil, err
+        }
+        _, P, _, err := ParseX509OCert(reply.Cert, issuerCertificate, cert.NotBefore)
+        if err != nil {
+            return nil, nil, err
+        }
+        return P, cert.SerialNumber.Bytes(), nil
+    }
+    if reply.Body == nil {
+        return nil, nil, fmt.Errorf("Legacy ocerts do not contain the pseudonym")
+    }
+
+    body := new(OCertBody)
+    err := body.SetBytes(reply.Body)
+    if err != nil {
+        return nil, nil, err
+    }
+    pk, err := getOCertPK(stub)
+    if err != nil {
+        return nil, nil, err
+    }
+    _, err = VerifyOCert(sharedParams, pk, reply.Body, reply.Sig, time.Unix(body.NotBefore, 0))
+    if err != nil {
+        return nil, nil, err
+    }
+    P := new(Pseudonym)
+    err = P.SetBytes(body.P)
+    if err != nil {
+        return nil, nil, err
+    }
+    return P, body.Serial, nil
+}
+
+/*
+ * RequestDeanonymization files a request to open the pseudonym of an
+ * ocert or a given pseudonym. It takes an encoded DeanonRequest and
+ * returns the DeanonRecord of the new request.
+ */
+func RequestDeanonymization(stub Wrapper, args [][]byte) ([]byte, error) {
+    if len(args) != 1 {
+        return nil, fmt.Errorf("Incorrect arguments. Expecting a de-anonymization request")
+    }
+    _, err := getDeanonPolicy(stub)
+    if err != nil {
+        return nil, err
+    }
+    requester, err := callerID(stub)
+    if err != nil {
+        return nil, err
+    }
+
+    request := new(DeanonRequest)
+    err = request.SetBytes(args[0])
+    if err != nil {
+        return nil, err
+    }
+    if request.Reason == "" {
+        return nil, fmt.Errorf("A de-anonymization request needs a reason")
+    }
+    // The result is only returned sealed, the response is in the block
+    _, err = ParseDeliveryKey(request.DeliveryKey)
+    if err != nil {
+        return nil, err
+    }
+
+    record := new(DeanonRecord)
+    if request.OCert != nil {
+        reply := new(GenOCertReply)
+        err = reply.SetBytes(request.OCert)
+        if err != nil {
+            return nil, err
+        }
+        record.P, record.Serial, err = ocertPseudonym(stub, reply)
+        if err != nil {
+            return nil, err
+        }
+    } else if request.P != nil {
+        record.P = new(Pseudonym)
+        err = record.P.SetBytes(request.P)
+        if err != nil {
+            return nil, err
+        }
+    } else {
+        return nil, fmt.Errorf("A de-anonymization request needs an ocert or a pseudonym")
+    }
+
+    // Request ids are assigned in order
+    record.ID, err = nextCount(stub, "deanon_request_count")
+    if err != nil {
+        return nil, err
+    }
+    record.Status = DeanonPending
+    record.Requester = requester
+    record.Reason = request.Reason
+    record.DeliveryKey = request.DeliveryKey
+    fmt.Printf("[Ocert Scheme] [RequestDeanonymization] request: %d\n", record.ID)
+    return putDeanonRecord(stub, record, "request", requester)
+}
+
+/*
+ * ApproveDeanonymization records the approval of the caller, who must be
+ * one of the approvers of the policy, for the request with the given id.
+ * The request is approved once the policy's number of approvals exist.
+ */
+func ApproveDeanonymization(stub Wrapper, args [][]byte) ([]byte, error) {
+    if len(args) != 1 {
+        return nil, fmt.Errorf("Incorrect arguments. Expecting a de-anonymization request id")
+    }
+    policy, err := getDeanonPolicy(stub)
+    if err != nil {
+        return nil, err
+    }
+    approver, err := requireApprover(stub, policy)
+    if err != nil {
+        return nil, err
+    }
+
+    record, err := getDeanonRecord(stub, args[0])
+    if err != nil {
+        return nil, err
+    }
+    if record.Status == DeanonOpened {
+        return nil, fmt.Errorf("De-anonymization request %d is already opened", record.ID)
+    }
+    for _, a := range record.Approvals {
+        if a == approver {
+            return nil, fmt.Errorf("De-anonymization request %d is already approved by %s", record.ID, approver)
+        }
+    }
+
+    record.Approvals = append(record.Approvals, approver)
+    if len(record.Approvals) >= policy.Required {
+        record.Status = DeanonApproved
+    }
+    fmt.Printf("[Ocert Scheme] [ApproveDeanonymization] request: %d approvals: %d of %d\n",
+        record.ID, len(record.Approvals), policy.Required)
+    return putDeanonRecord(stub, record, "approve", approver)
+}
+
+/*
+ * OpenDeanonymization opens the pseudonym of an approved request and
+ * returns the encoded DeanonResult to the requester, sealed to the
+ * delivery key of the request as a SealedDeanonResult. The id itself is
+ * not written to the ledger. If the auditor key is a
+ * threshold key, the partial decryptions of the auditors are passed in
+ * the transient map, see TransientPartials, as the args are recorded in
+ * the block.
+ */
+func OpenDeanonymization(stub Wrapper, args [][]byte) ([]byte, error) {
+    if len(args) != 1 {
+        return nil, fmt.Errorf("Incorrect arguments. Expecting a de-anonymization request id, the partial decryptions go in the transient map")
+    }
+    partials, err := transientPartials(stub)
+    if err != nil {
+        return nil, err
+    }
+    caller, err := callerID(stub)
+    if err != nil {
+        return nil, err
+    }
+    record, err := getDeanonRecord(stub, args[0])
+    if err != nil {
+        return nil, err
+    }
+    if caller != record.Requester {
+        return nil, fmt.Errorf("Only the requester can open de-anonymization request %d", record.ID)
+    }
+    if record.Status != DeanonApproved {
+        return nil, fmt.Errorf("De-anonymization request %d is %s", record.ID, record.Status)
+    }
+    recipient, err := ParseDeliveryKey(record.DeliveryKey)
+    if err != nil {
+        return nil, err
+    }
+
+    var id *ClientID
+    result := new(DeanonResult)
+    if partials == nil {
+        SKa, err := auditorSecretKey(stub)
+        if err != nil {
+            return nil, fmt.Errorf("%s, partial decryptions are required", err)
+        }
+        rand := auditorTxRandomness(stub, SKa, "openDeanonymization", args[0], "proof")
+        id, result.Proof = EDecWithProof(sharedParams, SKa, record.P, rand)
+    } else {
+        value, err := stub.GetState("auditor_threshold_pk")
+        if err != nil {
+            return nil, err
+        }
+        if value == nil {
+            return nil, fmt.Errorf("No threshold auditor public key")
+        }
+        TPKa := new(ThresholdAuditorPublicKey)
+        err = TPKa.SetBytes(value)
+        if err != nil {
+            return nil, err
+        }
+        id, err = EThresholdDec(sharedParams, TPKa, record.P, partials)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    // The opened client id is only kept in the audit record in the auditor
+    // collection, the public record does not commit to it
+    record.Status = DeanonOpened
+    _, err = putDeanonRecord(stub, record, "open", caller)
+    if err != nil {
+        return nil, err
+    }
+    fmt.Printf("[Ocert Scheme] [OpenDeanonymization] request: %d\n", record.ID)
+    result.ID = id.ID
+    result.EnrollmentID, err = LookupClientID(stub, id)
+    if err != nil {
+        return nil, err
+    }
+    audit := &AuditRecord{Kind: "open", Request: record.ID, ClientID: id.ID, EnrollmentID: result.EnrollmentID, Caller: caller}
+    if record.Serial != nil {
+        audit.Serials = [][]byte{record.Serial}
+    }
+    err = putAuditRecord(stub, audit)
+    if err != nil {
+        return nil, err
+    }
+    err = emitEvent(stub, &OCertEvent{Type: EventDeanonOpened, Serial: record.Serial, Request: record.ID})
+    if err != nil {
+        return nil, err
+    }
+    sealed, err := SealDeanonResult(recipient, result)
+    if err != nil {
+        return nil, err
+    }
+    return sealed.Bytes()
+}
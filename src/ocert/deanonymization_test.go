@@ -0,0 +1,165 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "reflect"
+    "testing"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * File a request for a pseudonym, approve it 2-of-3 and open it, checking
+ * that every step is refused when the caller or the state is wrong
+ */
+func TestDeanonymization(t *testing.T) {
+    stub := NewMemoryStub()
+    requester := []byte("requester")
+    approvers := [][]byte{[]byte("approver1"), []byte("approver2"), []byte("approver3")}
+
+    policy := new(DeanonPolicy)
+    policy.Required = 2
+    for _, approver := range approvers {
+        policy.Approvers = append(policy.Approvers, IdentityID(approver))
+    }
+    policyBytes, err := policy.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    KPab, err := Setup(stub, [][]byte{[]byte(""), nil, policyBytes})
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    reply := new(AuditorKeyReply)
+    if err := reply.SetBytes(KPab); err != nil {
+        t.Fatal(err)
+    }
+    PKa := new(AuditorPublicKey)
+    PKa.PK = reply.PK
+
+    id := new(ClientID)
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    id.ID = pairing.NewG1().Rand().Bytes()
+    P := EEnc(sharedParams, PKa, id, nil)
+    PBytes, err := P.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    request := new(DeanonRequest)
+    request.P = PBytes
+    request.Reason = "court order"
+    requestBytes, err := request.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // The result is only returned sealed to the requester
+    stub.Creator = requester
+    if _, err := RequestDeanonymization(stub, [][]byte{requestBytes}); err == nil {
+        t.Fatal("accepts a request without a delivery key")
+    }
+    key, deliveryKey := newTestDeliveryKey()
+    request.DeliveryKey = deliveryKey
+    requestBytes, err = request.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := RequestDeanonymization(stub, [][]byte{requestBytes}); err != nil {
+        t.Fatal(err)
+    }
+    requestID := []byte("1")
+
+    // Each step in order, as the given caller
+    steps := []struct {
+        name string
+        creator []byte
+        call func(Wrapper, [][]byte) ([]byte, error)
+        fails bool
+    }{
+        {"RequesterApproves", requester, ApproveDeanonymization, true},
+        {"Approve", approvers[0], ApproveDeanonymization, false},
+        {"ApproveTwice", approvers[0], ApproveDeanonymization, true},
+        {"OpenWithOneApproval", requester, OpenDeanonymization, true},
+        {"SecondApproval", approvers[2], ApproveDeanonymization, false},
+        {"ApproverOpens", approvers[2], OpenDeanonymization, true},
+    }
+    for _, step := range steps {
+        stub.Creator = step.creator
+        _, err := invokeTx(t, stub, 0, step.call, [][]byte{requestID})
+        if step.fails && err == nil {
+            t.Fatalf("%s: succeeds", step.name)
+        }
+        if !step.fails && err != nil {
+            t.Fatalf("%s: %v", step.name, err)
+        }
+    }
+
+    // Partial decryptions in the args would be recorded in the block
+    stub.Creator = requester
+    if _, err := invokeTx(t, stub, 0, OpenDeanonymization, [][]byte{requestID, []byte("{}")}); err == nil {
+        t.Error("accepts partial decryptions in the args")
+    }
+    resultBytes, err := invokeTx(t, stub, 1, OpenDeanonymization, [][]byte{requestID})
+    if err != nil {
+        t.Fatal(err)
+    }
+    sealed := new(SealedDeanonResult)
+    if err := sealed.SetBytes(resultBytes); err != nil {
+        t.Fatal(err)
+    }
+    result, err := OpenDeanonResult(key, sealed)
+    if err != nil {
+        t.Fatal(err)
+    }
+    openedID := new(ClientID)
+    openedID.ID = result.ID
+    if !reflect.DeepEqual(id, openedID) {
+        t.Error("opened another client id")
+    }
+    if !VerifyDecryption(sharedParams, PKa, P, openedID, result.Proof) {
+        t.Error("cannot verify the decryption proof")
+    }
+    event := stub.Events[len(stub.Events) - 1]
+    opened, err := DecodeOCertEvent(event.Name, event.Payload)
+    if err != nil || opened.Request != 1 {
+        t.Errorf("event %s %v: %v", event.Name, opened, err)
+    }
+
+    record, err := getDeanonRecord(stub, requestID)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if record.Status != DeanonOpened || len(record.Log) != 4 {
+        t.Errorf("status %v with audit trail %v", record.Status, record.Log)
+    }
+
+    // Nothing on the ledger points at the opened client id
+    hash := sha256.Sum256(id.ID)
+    for key, value := range stub.State {
+        for _, encoded := range []string{base64.StdEncoding.EncodeToString(id.ID), base64.StdEncoding.EncodeToString(hash[:]), hex.EncodeToString(hash[:])} {
+            if bytes.Contains(value, []byte(encoded)) || bytes.Contains([]byte(key), []byte(encoded)) {
+                t.Errorf("%s refers to the opened client id", key)
+            }
+        }
+    }
+}
@@ -0,0 +1,79 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Chaum-Pedersen proof of equality of discrete logarithms, made
+ * non-interactive with the Fiat-Shamir heuristic
+ */
+
+package ocert
+
+import (
+    "crypto/sha256"
+    "io"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * Hash the statement and the commitments of the prover to a challenge
+ * in Zr
+ */
+func dleqChallenge(pairing *pbc.Pairing, elements ...*pbc.Element) *pbc.Element {
+    h := sha256.New()
+    h.Write([]byte("ocert-dleq"))
+    for _, e := range elements {
+        h.Write(e.Bytes())
+    }
+    return pairing.NewZr().SetFromHash(h.Sum(nil))
+}
+
+/*
+ * Prove that X = x * g and Y = x * h for the same x, where g, X, h and
+ * Y are in G1. The nonce is drawn from rand, see randZr.
+ */
+func dleqProve(pairing *pbc.Pairing, g, X, h, Y, x *pbc.Element, rand io.Reader) *DLEQProof {
+    w := randZr(pairing, rand)
+    T1 := pairing.NewG1().MulZn(g, w)
+    T2 := pairing.NewG1().MulZn(h, w)
+
+    c := dleqChallenge(pairing, g, X, h, Y, T1, T2)
+    z := pairing.NewZr().Add(w, pairing.NewZr().Mul(c, x))
+
+    proof := new(DLEQProof)
+    proof.Challenge = c.Bytes()
+    proof.Response = z.Bytes()
+    return proof
+}
+
+/*
+ * Verify that X and Y have the same discrete logarithm to the bases g
+ * and h
+ */
+func dleqVerify(pairing *pbc.Pairing, g, X, h, Y *pbc.Element, proof *DLEQProof) bool {
+    if proof == nil {
+        return false
+    }
+    c := pairing.NewZr().SetBytes(proof.Challenge)
+    z := pairing.NewZr().SetBytes(proof.Response)
+
+    // T1 = z * g - c * X, T2 = z * h - c * Y
+    T1 := pairing.NewG1().Sub(pairing.NewG1().MulZn(g, z), pairing.NewG1().MulZn(X, c))
+    T2 := pairing.NewG1().Sub(pairing.NewG1().MulZn(h, z), pairing.NewG1().MulZn(Y, c))
+
+    return c.Equals(dleqChallenge(pairing, g, X, h, Y, T1, T2))
+}
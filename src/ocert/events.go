@@ -0,0 +1,74 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Chaincode events, so that other services learn about issued ecerts and
+ * ocerts and opened pseudonyms without polling the ledger. Fabric keeps one
+ * event per transaction, the event name is the type of the OCertEvent in
+ * its payload. Clients decode events received from the peer with
+ * DecodeOCertEvent.
+ */
+
+package ocert
+
+import (
+    "fmt"
+)
+
+const (
+    EventECertIssued  = "ecert_issued"
+    EventOCertIssued  = "ocert_issued"
+    EventDeanonOpened = "deanon_opened"
+)
+
+/*
+ * Set the event of the transaction, stamped with the transaction time
+ */
+func emitEvent(stub Wrapper, event *OCertEvent) error {
+    now, err := txTime(stub)
+    if err != nil {
+        return err
+    }
+    event.Timestamp = now
+    payload, err := event.Bytes()
+    if err != nil {
+        return err
+    }
+    return stub.SetEvent(event.Type, payload)
+}
+
+/*
+ * Decode a chaincode event by its name and payload. Events of other
+ * chaincodes or unknown types are rejected.
+ */
+func DecodeOCertEvent(name string, payload []byte) (*OCertEvent, error) {
+    switch name {
+    case EventECertIssued, EventOCertIssued, EventDeanonOpened:
+    default:
+        return nil, fmt.Errorf("Unknown event: %s", name)
+    }
+    event := new(OCertEvent)
+    err := event.SetBytes(payload)
+    if err != nil {
+        return nil, err
+    }
+    if event.Type != name {
+        return nil, fmt.Errorf("Event %s has payload of type %s", name, event.Type)
+    }
+    return event, nil
+}
@@ -0,0 +1,106 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "bytes"
+    "testing"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * GenECert emits an ecert_issued event with the epoch and issuer key
+ * version, and without the client id or the pseudonym
+ */
+func TestECertEvent(t *testing.T) {
+    stub := NewMemoryStub()
+    if _, err := Setup(stub, [][]byte{[]byte(""), nil}); err != nil {
+        t.Fatal(err)
+    }
+
+    stub.Creator = newTestCreator("Org1MSP", "alice")
+    request := new(GenECertRequest)
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    request.PKc = pairing.NewG2().Rand().Bytes()
+    requestBytes, err := request.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Transient = TransientRequest(requestBytes)
+    replyBytes, err := invokeTx(t, stub, 1, GenECert, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    reply := new(GenECertReply)
+    if err := reply.SetBytes(replyBytes); err != nil {
+        t.Fatal(err)
+    }
+    epoch := new(Epoch)
+    if err := epoch.SetBytes(reply.Epoch); err != nil {
+        t.Fatal(err)
+    }
+
+    if len(stub.Events) != 1 {
+        t.Fatalf("got %d events, want 1", len(stub.Events))
+    }
+    event, err := DecodeOCertEvent(stub.Events[0].Name, stub.Events[0].Payload)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if event.Type != EventECertIssued || event.Epoch != epoch.Epoch || event.VKVersion != reply.VKVersion || event.Timestamp == 0 {
+        t.Errorf("unexpected event %s", stub.Events[0].Payload)
+    }
+    IDcBytes, err := NewClientID(sharedParams, reply.EnrollmentID).Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    secrets := []struct {
+        name string
+        value []byte
+    }{
+        {"EnrollmentID", []byte(reply.EnrollmentID)},
+        {"ClientID", IDcBytes},
+        {"Pseudonym", reply.P},
+    }
+    for _, secret := range secrets {
+        if bytes.Contains(stub.Events[0].Payload, secret.value) {
+            t.Errorf("event payload contains the %s", secret.name)
+        }
+    }
+}
+
+/*
+ * Events of unknown types, or with a payload of another type, are
+ * rejected
+ */
+func TestDecodeOCertEvent(t *testing.T) {
+    event := &OCertEvent{Type: EventOCertIssued, Serial: []byte{1}}
+    payload, err := event.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    decoded, err := DecodeOCertEvent(EventOCertIssued, payload)
+    if err != nil || !bytes.Equal(decoded.Serial, event.Serial) {
+        t.Fatalf("decode: %v", err)
+    }
+    for _, name := range []string{EventDeanonOpened, "other"} {
+        if _, err := DecodeOCertEvent(name, payload); err == nil {
+            t.Errorf("decodes an %s payload as %q", EventOCertIssued, name)
+        }
+    }
+}
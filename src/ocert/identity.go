@@ -0,0 +1,226 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Encoding of enrollment ids as client ids. The enrollment id of a client
+ * is taken from the X.509 certificate of the transaction creator, and is
+ * hashed to an element of G1, so the same enrollment always gives the same
+ * ClientID. The hash can not be inverted, so every enrollment id that
+ * GenECert sees is recorded in the identity directory on the ledger, which
+ * resolves the ClientID returned by EDec back to the enrollment id.
+ */
+
+package ocert
+
+import (
+    "crypto/sha256"
+    "crypto/x509"
+    "encoding/hex"
+    "encoding/pem"
+    "fmt"
+    "strings"
+    "github.com/Nik-U/pbc"
+    "github.com/golang/protobuf/proto"
+    "github.com/hyperledger/fabric/protos/msp"
+)
+
+const IdentityDirectoryPrefix = "client_id_"
+
+/*
+ * The enrollment id in a serialized identity, as returned by GetCreator:
+ * the MSP ID and the subject of the enrollment certificate. The peer has
+ * validated the certificate against the MSP before the chaincode runs.
+ */
+func EnrollmentIDFromCreator(creator []byte) (string, error) {
+    identity := new(msp.SerializedIdentity)
+    err := proto.Unmarshal(creator, identity)
+    if err != nil {
+        return "", fmt.Errorf("Invalid creator: %s", err)
+    }
+    if identity.Mspid == "" {
+        return "", fmt.Errorf("Invalid creator: no MSP ID")
+    }
+    block, _ := pem.Decode(identity.IdBytes)
+    if block == nil {
+        return "", fmt.Errorf("Invalid creator: no PEM certificate")
+    }
+    cert, err := x509.ParseCertificate(block.Bytes)
+    if err != nil {
+        return "", fmt.Errorf("Invalid creator: %s", err)
+    }
+    return identity.Mspid + "::" + cert.Subject.String(), nil
+}
+
+/*
+ * The enrollment id of the transaction creator
+ */
+func CreatorEnrollmentID(stub Wrapper) (string, error) {
+    creator, err := stub.GetCreator()
+    if err != nil {
+        return "", err
+    }
+    return EnrollmentIDFromCreator(creator)
+}
+
+/*
+ * Serialize an enrollment certificate as the creator of a transaction,
+ * for Wrappers that do not run on a peer
+ */
+func NewSerializedIdentity(mspID string, cert *x509.Certificate) ([]byte, error) {
+    identity := new(msp.SerializedIdentity)
+    identity.Mspid = mspID
+    identity.IdBytes = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+    return proto.Marshal(identity)
+}
+
+/*
+ * Hash an enrollment id to G1
+ */
+func NewClientID(sharedParams *SharedParams, enrollmentID string) *ClientID {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    id := new(ClientID)
+    id.ID = pairing.NewG1().SetFromStringHash("ocert-client-id-" + enrollmentID, sha256.New()).Bytes()
+    return id
+}
+
+/*
+ * The directory key of id. It is domain separated, so it is not the hash
+ * of the client id that any other record might publish.
+ */
+func identityKey(id *ClientID) string {
+    hash := sha256.Sum256(append([]byte("ocert-identity-directory"), id.ID...))
+    return IdentityDirectoryPrefix + hex.EncodeToString(hash[:])
+}
+
+/*
+ * Record the enrollment id of id in the identity directory
+ */
+func registerClientID(stub Wrapper, enrollmentID string, id *ClientID) error {
+    key := identityKey(id)
+    value, err := stub.GetState(key)
+    if err != nil {
+        return err
+    }
+    if value != nil {
+        if string(value) != enrollmentID {
+            return fmt.Errorf("Client id of %s is taken by another enrollment id", enrollmentID)
+        }
+        return nil
+    }
+    return stub.PutState(key, []byte(enrollmentID))
+}
+
+/*
+ * Look up the enrollment id of id in the identity directory. It returns
+ * an empty string if id was not issued from an enrollment id.
+ */
+func LookupClientID(stub Wrapper, id *ClientID) (string, error) {
+    value, err := stub.GetState(identityKey(id))
+    if err != nil {
+        return "", err
+    }
+    return string(value), nil
+}
+
+/*
+ * Refuse a transaction creator that is in the identity directory. The
+ * creator is recorded in the block, so an ocert submitted by an enrolled
+ * client would be linked to its enrollment. Creators without an X.509
+ * enrollment, e.g. Idemix identities, are never in the directory.
+ */
+func requireUnlinkedCreator(stub Wrapper) error {
+    creator, err := stub.GetCreator()
+    if err != nil {
+        return err
+    }
+    enrollmentID, err := EnrollmentIDFromCreator(creator)
+    if err != nil {
+        return nil
+    }
+    value, err := LookupClientID(stub, NewClientID(sharedParams, enrollmentID))
+    if err != nil {
+        return err
+    }
+    if value != "" {
+        return fmt.Errorf("%s is an enrolled client, submit the ocert request with an identity that is not linked to it", enrollmentID)
+    }
+    return nil
+}
+
+/*
+ * An in-memory identity directory, for the auditor working offline
+ */
+type IdentityDirectory struct {
+    entries map[string]string
+}
+
+func NewIdentityDirectory() *IdentityDirectory {
+    directory := new(IdentityDirectory)
+    directory.entries = make(map[string]string)
+    return directory
+}
+
+/*
+ * Load the identity directory from an exported ledger snapshot, as
+ * LoadOCertRegistry does for the ocert registry
+ */
+func LoadIdentityDirectory(snapshot map[string][]byte) *IdentityDirectory {
+    directory := NewIdentityDirectory()
+    for key, value := range snapshot {
+        if strings.HasPrefix(key, IdentityDirectoryPrefix) {
+            directory.entries[key] = string(value)
+        }
+    }
+    return directory
+}
+
+/*
+ * Add an enrollment id to the directory and return its client id
+ */
+func (directory *IdentityDirectory) Add(sharedParams *SharedParams, enrollmentID string) *ClientID {
+    id := NewClientID(sharedParams, enrollmentID)
+    directory.entries[identityKey(id)] = enrollmentID
+    return id
+}
+
+func (directory *IdentityDirectory) Resolve(id *ClientID) (string, bool) {
+    enrollmentID, ok := directory.entries[identityKey(id)]
+    return enrollmentID, ok
+}
+
+/*
+ * Chaincode function to resolve an encoded ClientID to its enrollment id
+ */
+func ResolveClientID(stub Wrapper, args [][]byte) ([]byte, error) {
+    if len(args) != 1 {
+        return nil, fmt.Errorf("Incorrect arguments. Expecting a client id")
+    }
+    id := new(ClientID)
+    err := id.SetBytes(args[0])
+    if err != nil {
+        return nil, err
+    }
+    enrollmentID, err := LookupClientID(stub, id)
+    if err != nil {
+        return nil, err
+    }
+    if enrollmentID == "" {
+        return nil, fmt.Errorf("Unknown client id")
+    }
+    return []byte(enrollmentID), nil
+}
@@ -0,0 +1,211 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "bytes"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "math/big"
+    "testing"
+    "time"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * A serialized identity with a self-signed enrollment certificate
+ */
+func newTestCreator(mspID string, commonName string) []byte {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        panic(err)
+    }
+    template := &x509.Certificate{
+        SerialNumber: big.NewInt(1),
+        Subject:      pkix.Name{CommonName: commonName},
+        NotBefore:    time.Now(),
+        NotAfter:     time.Now().Add(time.Hour),
+    }
+    der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+    if err != nil {
+        panic(err)
+    }
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        panic(err)
+    }
+    creator, err := NewSerializedIdentity(mspID, cert)
+    if err != nil {
+        panic(err)
+    }
+    return creator
+}
+
+/*
+ * The same enrollment id always gives the same client id, different
+ * enrollment ids give different ones
+ */
+func TestClientIDEncoding(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    alice := NewClientID(sharedParams, "alice")
+    if !bytes.Equal(alice.ID, NewClientID(sharedParams, "alice").ID) {
+        t.Error("same enrollment id gives different client ids")
+    }
+    if bytes.Equal(alice.ID, NewClientID(sharedParams, "bob").ID) {
+        t.Error("different enrollment ids give the same client id")
+    }
+}
+
+/*
+ * The enrollment id is the MSP ID and subject of the creator certificate,
+ * anything else is rejected
+ */
+func TestCreatorEnrollmentID(t *testing.T) {
+    stub := NewMemoryStub()
+    stub.Creator = newTestCreator("Org1MSP", "alice")
+    enrollmentID, err := CreatorEnrollmentID(stub)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if enrollmentID != "Org1MSP::CN=alice" {
+        t.Errorf("got enrollment id %q", enrollmentID)
+    }
+    other, err := EnrollmentIDFromCreator(newTestCreator("Org2MSP", "alice"))
+    if err != nil || other == enrollmentID {
+        t.Errorf("another MSP gives enrollment id %q: %v", other, err)
+    }
+
+    stub.Creator = []byte("alice")
+    if _, err := CreatorEnrollmentID(stub); err == nil {
+        t.Error("accepts a creator without a certificate")
+    }
+}
+
+/*
+ * An enrolled client may not submit its own ocert request, another
+ * enrollment or an identity without a certificate may
+ */
+func TestUnlinkedCreator(t *testing.T) {
+    stub := NewMemoryStub()
+    if _, err := Setup(stub, [][]byte{[]byte("")}); err != nil {
+        t.Fatal(err)
+    }
+    alice := newTestCreator("Org1MSP", "alice")
+    enrollmentID, err := EnrollmentIDFromCreator(alice)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := registerClientID(stub, enrollmentID, NewClientID(sharedParams, enrollmentID)); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+
+    stub.Creator = alice
+    if requireUnlinkedCreator(stub) == nil {
+        t.Error("an enrolled client submits its ocert request")
+    }
+    stub.Creator = newTestCreator("Org1MSP", "relay")
+    if err := requireUnlinkedCreator(stub); err != nil {
+        t.Errorf("refuses another enrollment: %v", err)
+    }
+    stub.Creator = []byte("idemix")
+    if err := requireUnlinkedCreator(stub); err != nil {
+        t.Errorf("refuses an identity without a certificate: %v", err)
+    }
+}
+
+/*
+ * Issue an ecert for the enrollment of the caller and resolve the opened
+ * pseudonym on the ledger and offline
+ */
+func TestIdentityDirectory(t *testing.T) {
+    stub := NewMemoryStub()
+    key, deliveryKey := newTestDeliveryKey()
+    KPab, err := Setup(stub, [][]byte{[]byte(""), nil, nil, deliveryKey})
+    if err != nil {
+        t.Fatal(err)
+    }
+    KPa, err := openTestKeypair(key, KPab)
+    if err != nil {
+        t.Fatal(err)
+    }
+    SKa := new(AuditorSecretKey)
+    SKa.SK = KPa.SK
+
+    stub.Creator = newTestCreator("Org1MSP", "alice")
+    request := new(GenECertRequest)
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    request.PKc = pairing.NewG2().Rand().Bytes()
+    requestBytes, err := request.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Transient = TransientRequest(requestBytes)
+    replyBytes, err := invokeTx(t, stub, 1, GenECert, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    reply := new(GenECertReply)
+    if err := reply.SetBytes(replyBytes); err != nil {
+        t.Fatal(err)
+    }
+    if reply.EnrollmentID != "Org1MSP::CN=alice" {
+        t.Errorf("issued for enrollment id %q", reply.EnrollmentID)
+    }
+    P := new(Pseudonym)
+    if err := P.SetBytes(reply.P); err != nil {
+        t.Fatal(err)
+    }
+
+    id := EDec(sharedParams, SKa, P)
+    idBytes, err := id.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    enrollmentID, err := ResolveClientID(stub, [][]byte{idBytes})
+    if err != nil || string(enrollmentID) != reply.EnrollmentID {
+        t.Errorf("resolved %q: %v", enrollmentID, err)
+    }
+    resolved, ok := LoadIdentityDirectory(stub.State).Resolve(id)
+    if !ok || resolved != reply.EnrollmentID {
+        t.Errorf("resolved %q offline", resolved)
+    }
+
+    // Unknown client ids do not resolve
+    unknownBytes, err := NewClientID(sharedParams, "Org1MSP::CN=bob").Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := ResolveClientID(stub, [][]byte{unknownBytes}); err == nil {
+        t.Error("resolves an unknown client id")
+    }
+
+    // A client can not choose its client id
+    request.IDc = NewClientID(sharedParams, "Org1MSP::CN=bob").ID
+    requestBytes, err = request.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Transient = TransientRequest(requestBytes)
+    if _, err := GenECert(stub, nil); err == nil {
+        t.Error("accepts a request with a client id")
+    }
+}
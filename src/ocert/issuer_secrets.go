@@ -0,0 +1,179 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Issuer secrets in the private data collection of the issuer org. The
+ * issuer seed, from which the ocert signing key, the structure preserving
+ * signing keys and the randomness of GenECert, GenOCert and ReissueECert
+ * are derived, is provisioned once at Setup, so every endorsing peer of
+ * the issuer holds the same seed and signing keys and endorsements of the
+ * same proposal agree.
+ */
+
+package ocert
+
+import (
+    "crypto/rand"
+    "fmt"
+    "strconv"
+)
+
+const IssuerCollection = "issuerCollection"
+const IssuerSeedKey = "issuer_seed"
+const IssuerSeedSize = 32
+const OCertSKKey = "ocert_sk"
+
+/*
+ * The public ledger key of the name of the structure preserving scheme
+ * chosen at Setup, see GetSPSScheme. Ledgers set up before the scheme
+ * could be chosen have none, which means AGHO.
+ */
+const SPSSchemeKey = "structure_preserving_scheme"
+
+func getSPSScheme(stub Wrapper) (SPSScheme, error) {
+    value, err := stub.GetState(SPSSchemeKey)
+    if err != nil {
+        return nil, err
+    }
+    return GetSPSScheme(string(value))
+}
+
+func putSPSScheme(stub Wrapper, scheme SPSScheme) error {
+    return stub.PutState(SPSSchemeKey, []byte(scheme.Name()))
+}
+
+/*
+ * The issuer seed in the transient map of Setup. Without one a random
+ * seed is drawn, which only works if Setup is endorsed by a single peer.
+ */
+func transientIssuerSeed(stub Wrapper) ([]byte, error) {
+    transient, err := stub.GetTransient()
+    if err != nil {
+        return nil, err
+    }
+    seed, ok := transient[IssuerSeedKey]
+    if !ok {
+        seed = make([]byte, IssuerSeedSize)
+        _, err = rand.Read(seed)
+        if err != nil {
+            return nil, err
+        }
+    }
+    if len(seed) < IssuerSeedSize {
+        return nil, fmt.Errorf("The issuer seed must have at least %d bytes", IssuerSeedSize)
+    }
+    return seed, nil
+}
+
+func getIssuerSeed(stub Wrapper) ([]byte, error) {
+    value, err := stub.GetPrivateData(IssuerCollection, IssuerSeedKey)
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, fmt.Errorf("No issuer seed")
+    }
+    return value, nil
+}
+
+func putIssuerSeed(stub Wrapper, seed []byte) error {
+    return stub.PutPrivateData(IssuerCollection, IssuerSeedKey, seed)
+}
+
+/*
+ * Generate the structure preserving keypair of the given version from the
+ * issuer seed, with the scheme chosen at Setup, and keep the signing key
+ * in the issuer collection. The seed and scheme are passed in, as Setup
+ * cannot read back what it writes in the same transaction.
+ */
+func newIssuerKey(stub Wrapper, seed []byte, scheme SPSScheme, version int) (*SVerificationKey, error) {
+    rand := NewPRFReader(seed, []byte("structure-preserving-key"), []byte(strconv.Itoa(version)))
+    VKei, SKei := scheme.KeyGen(sharedParams, rand)
+    value, err := SKei.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    err = stub.PutPrivateData(IssuerCollection, "structure_preserving_sk_" + strconv.Itoa(version), value)
+    if err != nil {
+        return nil, err
+    }
+    return VKei, nil
+}
+
+/*
+ * Generate the ocert signer of the algorithm from the issuer seed and
+ * keep its private key in the issuer collection
+ */
+func newOCertSigner(stub Wrapper, seed []byte, algorithm string) (OCertSigner, error) {
+    rand := NewPRFReader(seed, []byte("ocert-signer"), []byte(algorithm))
+    signer, err := NewOCertSigner(sharedParams, algorithm, rand)
+    if err != nil {
+        return nil, err
+    }
+    value, err := signer.PrivateKey()
+    if err != nil {
+        return nil, err
+    }
+    err = stub.PutPrivateData(IssuerCollection, OCertSKKey, value)
+    if err != nil {
+        return nil, err
+    }
+    return signer, nil
+}
+
+/*
+ * The ocert signer of the algorithm of ocert_pk
+ */
+func issuerOCertSigner(stub Wrapper) (OCertSigner, error) {
+    pk, err := getOCertPK(stub)
+    if err != nil {
+        return nil, err
+    }
+    value, err := stub.GetPrivateData(IssuerCollection, OCertSKKey)
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, fmt.Errorf("Asset not found: %s", OCertSKKey)
+    }
+    return ParseOCertSigner(sharedParams, pk.Algorithm, value)
+}
+
+/*
+ * The signing key of the current verification key version
+ */
+func issuerSigningKey(stub Wrapper) (*SSigningKey, error) {
+    version, err := getSVKVersion(stub)
+    if err != nil {
+        return nil, err
+    }
+    key := "structure_preserving_sk_" + strconv.Itoa(version)
+    value, err := stub.GetPrivateData(IssuerCollection, key)
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, fmt.Errorf("Asset not found: %s", key)
+    }
+    SKei := new(SSigningKey)
+    err = SKei.SetBytes(value)
+    if err != nil {
+        return nil, err
+    }
+    return SKei, nil
+}
@@ -0,0 +1,145 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "bytes"
+    "encoding/base64"
+    "reflect"
+    "testing"
+)
+
+/*
+ * The issuer seed of the transient map of Setup is kept in the issuer
+ * collection only, and a peer given the same seed derives the same
+ * signing key
+ */
+func TestIssuerSeed(t *testing.T) {
+    seed := bytes.Repeat([]byte{7}, IssuerSeedSize)
+    stub := NewMemoryStub()
+    stub.Transient = map[string][]byte{IssuerSeedKey: seed}
+    if _, err := Setup(stub, [][]byte{[]byte("")}); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    stored, err := getIssuerSeed(stub)
+    if err != nil || !bytes.Equal(stored, seed) {
+        t.Fatalf("stored seed %x: %v", stored, err)
+    }
+    seedb64 := []byte(base64.StdEncoding.EncodeToString(seed))
+    for key, value := range stub.State {
+        if bytes.Contains(value, seed) || bytes.Contains(value, seedb64) {
+            t.Errorf("issuer seed in public state %q", key)
+        }
+    }
+    SKei, err := issuerSigningKey(stub)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    other := NewMemoryStub()
+    other.State["structure_preserving_vk_version"] = stub.State["structure_preserving_vk_version"]
+    if _, err := newIssuerKey(other, seed, new(AGHOScheme), 0); err != nil {
+        t.Fatal(err)
+    }
+    other.NextTx()
+    otherSKei, err := issuerSigningKey(other)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(SKei, otherSKei) {
+        t.Error("the same seed gives different signing keys")
+    }
+
+    // A short seed is refused
+    stub = NewMemoryStub()
+    stub.Transient = map[string][]byte{IssuerSeedKey: seed[:IssuerSeedSize - 1]}
+    if _, err := Setup(stub, [][]byte{[]byte("")}); err == nil {
+        t.Error("accepts a short issuer seed")
+    }
+
+    // A peer that is not a member of the issuer collection cannot sign
+    stub = NewMemoryStub()
+    stub.NotMember[IssuerCollection] = true
+    if _, err := Setup(stub, [][]byte{[]byte("")}); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    if _, err := issuerSigningKey(stub); err == nil {
+        t.Error("signs on a peer that is not a member of the issuer collection")
+    }
+}
+
+/*
+ * The structure preserving scheme chosen at Setup is kept on the ledger,
+ * and the issuer keys, also the rotated ones, are of that scheme
+ */
+func TestIssuerSPSScheme(t *testing.T) {
+    stub := NewMemoryStub()
+    args := [][]byte{[]byte(""), nil, nil, nil, []byte(SPSSchemeDualAGHO)}
+    if _, err := Setup(stub, args); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    if scheme := string(stub.State[SPSSchemeKey]); scheme != SPSSchemeDualAGHO {
+        t.Fatalf("scheme %q on the ledger", scheme)
+    }
+    SKei, err := issuerSigningKey(stub)
+    if err != nil {
+        t.Fatal(err)
+    }
+    VK := new(SVerificationKey)
+    if err := VK.SetBytes(stub.State["structure_preserving_vk"]); err != nil {
+        t.Fatal(err)
+    }
+    if SKei.Scheme != SPSSchemeDualAGHO || VK.Scheme != SPSSchemeDualAGHO {
+        t.Errorf("issuer key of scheme %q", SKei.Scheme)
+    }
+
+    // A rotation reads the scheme from the ledger
+    scheme, err := getSPSScheme(stub)
+    if err != nil {
+        t.Fatal(err)
+    }
+    seed, err := getIssuerSeed(stub)
+    if err != nil {
+        t.Fatal(err)
+    }
+    VKei, err := newIssuerKey(stub, seed, scheme, 1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if VKei.Scheme != SPSSchemeDualAGHO {
+        t.Errorf("rotated issuer key of scheme %q", VKei.Scheme)
+    }
+
+    // Without the argument it is AGHO, and unknown schemes are refused
+    stub = NewMemoryStub()
+    if _, err := Setup(stub, [][]byte{[]byte("")}); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    if scheme := string(stub.State[SPSSchemeKey]); scheme != SPSSchemeAGHO {
+        t.Errorf("scheme %q on the ledger by default", scheme)
+    }
+    stub = NewMemoryStub()
+    args[4] = []byte("unknown")
+    if _, err := Setup(stub, args); err == nil {
+        t.Error("sets up with an unknown scheme")
+    }
+}
@@ -0,0 +1,220 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Delivery of the auditor keypair. The keypair is never returned in
+ * plaintext, since chaincode responses end up in transactions. It is
+ * sealed (ECIES: ephemeral ECDH, SHA-256 and AES-GCM) to the delivery
+ * key of the auditor, an ECDSA public key passed to Setup, and only the
+ * auditor can open it with OpenAuditorKeypair. Without a delivery key the
+ * keypair is only kept in the auditor collection, and the auditor reads
+ * it from a peer of that collection.
+ * The result of a de-anonymization is sealed the same way, to the
+ * delivery key in the request, and opened with OpenDeanonResult.
+ */
+
+package ocert
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/sha256"
+    "crypto/x509"
+    "encoding/pem"
+    "fmt"
+    "math/big"
+)
+
+/*
+ * Parse the delivery key of the auditor, a PEM encoded ECDSA public key
+ * or a certificate with one, e.g. the enrollment certificate
+ */
+func ParseDeliveryKey(value []byte) (*ecdsa.PublicKey, error) {
+    block, _ := pem.Decode(value)
+    if block == nil {
+        return nil, fmt.Errorf("Invalid delivery key: not PEM encoded")
+    }
+    var key interface{}
+    var err error
+    if block.Type == "CERTIFICATE" {
+        var cert *x509.Certificate
+        cert, err = x509.ParseCertificate(block.Bytes)
+        if err == nil {
+            key = cert.PublicKey
+        }
+    } else {
+        key, err = x509.ParsePKIXPublicKey(block.Bytes)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("Invalid delivery key: %s", err)
+    }
+    PK, ok := key.(*ecdsa.PublicKey)
+    if !ok {
+        return nil, fmt.Errorf("Invalid delivery key: not an ECDSA key")
+    }
+    return PK, nil
+}
+
+/*
+ * The AEAD keyed by the shared secret of the ephemeral key and the
+ * delivery key, for what label names
+ */
+func deliveryCipher(label string, curve elliptic.Curve, shared *big.Int, ephemeral []byte) (cipher.AEAD, error) {
+    // Fixed length encoding of the shared x coordinate
+    x := make([]byte, (curve.Params().BitSize + 7) / 8)
+    sharedBytes := shared.Bytes()
+    copy(x[len(x) - len(sharedBytes):], sharedBytes)
+
+    h := sha256.New()
+    h.Write([]byte(label))
+    h.Write(x)
+    h.Write(ephemeral)
+    block, err := aes.NewCipher(h.Sum(nil))
+    if err != nil {
+        return nil, err
+    }
+    return cipher.NewGCM(block)
+}
+
+func sealBytes(label string, recipient *ecdsa.PublicKey, plaintext []byte) (*SealedAuditorKeypair, error) {
+    ephemeral, err := ecdsa.GenerateKey(recipient.Curve, rand.Reader)
+    if err != nil {
+        return nil, err
+    }
+    shared, _ := recipient.Curve.ScalarMult(recipient.X, recipient.Y, ephemeral.D.Bytes())
+
+    sealed := new(SealedAuditorKeypair)
+    sealed.Ephemeral = elliptic.Marshal(recipient.Curve, ephemeral.X, ephemeral.Y)
+    aead, err := deliveryCipher(label, recipient.Curve, shared, sealed.Ephemeral)
+    if err != nil {
+        return nil, err
+    }
+    sealed.Nonce = make([]byte, aead.NonceSize())
+    _, err = rand.Read(sealed.Nonce)
+    if err != nil {
+        return nil, err
+    }
+    sealed.Ciphertext = aead.Seal(nil, sealed.Nonce, plaintext, sealed.Ephemeral)
+    return sealed, nil
+}
+
+func openBytes(label string, recipient *ecdsa.PrivateKey, sealed *SealedAuditorKeypair) ([]byte, error) {
+    curve := recipient.Curve
+    x, y := elliptic.Unmarshal(curve, sealed.Ephemeral)
+    if x == nil {
+        return nil, fmt.Errorf("Invalid ephemeral key")
+    }
+    shared, _ := curve.ScalarMult(x, y, recipient.D.Bytes())
+    aead, err := deliveryCipher(label, curve, shared, sealed.Ephemeral)
+    if err != nil {
+        return nil, err
+    }
+    if len(sealed.Nonce) != aead.NonceSize() {
+        return nil, fmt.Errorf("Invalid nonce")
+    }
+    return aead.Open(nil, sealed.Nonce, sealed.Ciphertext, sealed.Ephemeral)
+}
+
+func SealAuditorKeypair(recipient *ecdsa.PublicKey, KPa *AuditorKeypair) (*SealedAuditorKeypair, error) {
+    plaintext, err := KPa.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    return sealBytes("ocert-auditor-keypair", recipient, plaintext)
+}
+
+/*
+ * Open the sealed auditor keypair with the private delivery key
+ */
+func OpenAuditorKeypair(recipient *ecdsa.PrivateKey, sealed *SealedAuditorKeypair) (*AuditorKeypair, error) {
+    plaintext, err := openBytes("ocert-auditor-keypair", recipient, sealed)
+    if err != nil {
+        return nil, fmt.Errorf("Cannot open the auditor keypair: %s", err)
+    }
+    KPa := new(AuditorKeypair)
+    err = KPa.SetBytes(plaintext)
+    if err != nil {
+        return nil, err
+    }
+    return KPa, nil
+}
+
+/*
+ * Reads the delivery key of the auditor from the ledger, nil if Setup was
+ * given none
+ */
+func getDeliveryKey(stub Wrapper) (*ecdsa.PublicKey, error) {
+    value, err := stub.GetState("auditor_delivery_key")
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, nil
+    }
+    return ParseDeliveryKey(value)
+}
+
+/*
+ * The reply to the auditor for a new keypair: the public key, and the
+ * keypair sealed to the delivery key if there is one. The caller passes
+ * the key, since Setup cannot read back the one it just wrote
+ */
+func auditorKeyReply(recipient *ecdsa.PublicKey, KPa *AuditorKeypair) ([]byte, error) {
+    reply := new(AuditorKeyReply)
+    reply.PK = KPa.PK
+    if recipient != nil && KPa.SK != nil {
+        var err error
+        reply.Sealed, err = SealAuditorKeypair(recipient, KPa)
+        if err != nil {
+            return nil, err
+        }
+    }
+    return reply.Bytes()
+}
+
+func SealDeanonResult(recipient *ecdsa.PublicKey, result *DeanonResult) (*SealedDeanonResult, error) {
+    plaintext, err := result.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    sealed, err := sealBytes("ocert-deanon-result", recipient, plaintext)
+    if err != nil {
+        return nil, err
+    }
+    return (*SealedDeanonResult)(sealed), nil
+}
+
+/*
+ * Open the sealed result of a de-anonymization with the private delivery
+ * key of the requester
+ */
+func OpenDeanonResult(recipient *ecdsa.PrivateKey, sealed *SealedDeanonResult) (*DeanonResult, error) {
+    plaintext, err := openBytes("ocert-deanon-result", recipient, (*SealedAuditorKeypair)(sealed))
+    if err != nil {
+        return nil, fmt.Errorf("Cannot open the de-anonymization result: %s", err)
+    }
+    result := new(DeanonResult)
+    err = result.SetBytes(plaintext)
+    if err != nil {
+        return nil, err
+    }
+    return result, nil
+}
@@ -0,0 +1,186 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "bytes"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/pem"
+    "fmt"
+    "testing"
+)
+
+/*
+ * A delivery key of the auditor and its PEM encoded public key
+ */
+func newTestDeliveryKey() (*ecdsa.PrivateKey, []byte) {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        panic(err)
+    }
+    der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+    if err != nil {
+        panic(err)
+    }
+    return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+/*
+ * Open the keypair in a reply of Setup or RotateAuditorKey
+ */
+func openTestKeypair(key *ecdsa.PrivateKey, replyBytes []byte) (*AuditorKeypair, error) {
+    reply := new(AuditorKeyReply)
+    err := reply.SetBytes(replyBytes)
+    if err != nil {
+        return nil, err
+    }
+    if reply.Sealed == nil {
+        return nil, fmt.Errorf("No sealed keypair")
+    }
+    KPa, err := OpenAuditorKeypair(key, reply.Sealed)
+    if err != nil {
+        return nil, err
+    }
+    if !bytes.Equal(KPa.PK, reply.PK) {
+        return nil, fmt.Errorf("Sealed keypair does not match the public key")
+    }
+    return KPa, nil
+}
+
+/*
+ * Only the delivery key opens a sealed keypair, and a modified keypair
+ * does not open
+ */
+func TestSealAuditorKeypair(t *testing.T) {
+    key, pemKey := newTestDeliveryKey()
+    recipient, err := ParseDeliveryKey(pemKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    KPa := &AuditorKeypair{PK: []byte("public"), SK: []byte("secret")}
+    sealed, err := SealAuditorKeypair(recipient, KPa)
+    if err != nil {
+        t.Fatal(err)
+    }
+    opened, err := OpenAuditorKeypair(key, sealed)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.Equal(opened.SK, KPa.SK) {
+        t.Error("opened a different secret key")
+    }
+
+    other, _ := newTestDeliveryKey()
+    if _, err := OpenAuditorKeypair(other, sealed); err == nil {
+        t.Error("another delivery key opens the keypair")
+    }
+    sealed.Ciphertext[0] ^= 1
+    if _, err := OpenAuditorKeypair(key, sealed); err == nil {
+        t.Error("opens a modified keypair")
+    }
+}
+
+/*
+ * A de-anonymization result opens with the delivery key of the requester
+ * only, and not as an auditor keypair
+ */
+func TestSealDeanonResult(t *testing.T) {
+    key, pemKey := newTestDeliveryKey()
+    recipient, err := ParseDeliveryKey(pemKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    result := &DeanonResult{ID: []byte("client id"), EnrollmentID: "Org1MSP::CN=alice"}
+    sealed, err := SealDeanonResult(recipient, result)
+    if err != nil {
+        t.Fatal(err)
+    }
+    opened, err := OpenDeanonResult(key, sealed)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.Equal(opened.ID, result.ID) || opened.EnrollmentID != result.EnrollmentID {
+        t.Errorf("opened %v", opened)
+    }
+
+    other, _ := newTestDeliveryKey()
+    if _, err := OpenDeanonResult(other, sealed); err == nil {
+        t.Error("another delivery key opens the result")
+    }
+    if _, err := OpenAuditorKeypair(key, (*SealedAuditorKeypair)(sealed)); err == nil {
+        t.Error("the result opens as an auditor keypair")
+    }
+}
+
+/*
+ * Setup never returns the plaintext secret key: it is sealed to the
+ * delivery key, or not returned at all without one
+ */
+func TestSetupKeyDelivery(t *testing.T) {
+    t.Run("DeliveryKey", func(t *testing.T) {
+        key, pemKey := newTestDeliveryKey()
+        stub := NewMemoryStub()
+        replyBytes, err := Setup(stub, [][]byte{[]byte(""), nil, nil, pemKey})
+        if err != nil {
+            t.Fatal(err)
+        }
+        KPa, err := openTestKeypair(key, replyBytes)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if bytes.Contains(replyBytes, []byte(base64.StdEncoding.EncodeToString(KPa.SK))) {
+            t.Error("reply contains the plaintext secret key")
+        }
+        stub.NextTx()
+        fetched, err := GetAuditorKeypair(stub, nil)
+        if err != nil {
+            t.Fatal(err)
+        }
+        fetchedKPa, err := openTestKeypair(key, fetched)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !bytes.Equal(fetchedKPa.SK, KPa.SK) {
+            t.Error("fetched a different secret key")
+        }
+    })
+
+    // Without a delivery key only the public key is returned
+    t.Run("NoDeliveryKey", func(t *testing.T) {
+        stub := NewMemoryStub()
+        replyBytes, err := Setup(stub, [][]byte{[]byte("")})
+        if err != nil {
+            t.Fatal(err)
+        }
+        reply := new(AuditorKeyReply)
+        if err := reply.SetBytes(replyBytes); err != nil {
+            t.Fatal(err)
+        }
+        if reply.PK == nil || reply.Sealed != nil {
+            t.Error("reply is not only the public key")
+        }
+        stub.NextTx()
+        if _, err := GetAuditorKeypair(stub, nil); err == nil {
+            t.Error("returns a keypair without a delivery key")
+        }
+    })
+}
@@ -0,0 +1,685 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Rotation of the auditor key and of the issuer's structure preserving
+ * signing key.
+ *
+ * The ledger keeps every auditor public key under auditor_pk_<version>
+ * and the first ecert epoch it is used in under auditor_key_epoch_<version>;
+ * auditor_key_version is the current version and auditor_pk the current
+ * key. Rotating the key starts a new epoch and re-encrypts the stored
+ * pseudonyms under the new key, and clients get their ecerts of the last
+ * epoch of the old key re-issued for the re-encrypted pseudonyms. The
+ * ocert registry may be too large for one transaction, it is re-encrypted
+ * page by page afterwards.
+ *  - RotateAuditorKey
+ *  - ReEncryptOCertRegistry
+ *  - ReissueECert
+ *
+ * The verification keys of the issuer are versioned as well: the current
+ * key is structure_preserving_vk, every version is kept under
+ * structure_preserving_vk_<version> and structure_preserving_vk_version is
+ * the current version. After RotateIssuerKey, proofs for ecerts of the old
+ * key are accepted for a grace period, and GetIssuerKeyStatus tells
+ * clients to refresh their ecerts.
+ *  - RotateIssuerKey
+ *  - GetIssuerKeyStatus
+ */
+
+package ocert
+
+import (
+    "fmt"
+    "strconv"
+    "time"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * How long proofs for ecerts signed with an old issuer key are accepted
+ * after the key is rotated
+ */
+const SVKGracePeriod = 7 * 24 * time.Hour
+
+/*
+ * How many registry records ReEncryptOCertRegistry re-encrypts in one
+ * transaction by default
+ */
+const OCertRegistryPageSize = 100
+
+func getAuditorKeyVersion(stub Wrapper) (int, error) {
+    value, err := stub.GetState("auditor_key_version")
+    if err != nil {
+        return 0, err
+    }
+    if value == nil {
+        return 0, nil
+    }
+    return strconv.Atoi(string(value))
+}
+
+func getAuditorKeyEpoch(stub Wrapper, version int) (uint64, error) {
+    value, err := stub.GetState("auditor_key_epoch_" + strconv.Itoa(version))
+    if err != nil {
+        return 0, err
+    }
+    if value == nil {
+        return 0, fmt.Errorf("Asset not found: auditor_key_epoch_%d", version)
+    }
+    return strconv.ParseUint(string(value), 10, 64)
+}
+
+/*
+ * The current auditor public key
+ */
+func getAuditorPublicKey(stub Wrapper) (*AuditorPublicKey, error) {
+    value, err := stub.GetState("auditor_pk")
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, fmt.Errorf("Asset not found: auditor_pk")
+    }
+    PKa := new(AuditorPublicKey)
+    err = PKa.SetBytes(value)
+    if err != nil {
+        return nil, err
+    }
+    return PKa, nil
+}
+
+/*
+ * Store PKa as the current auditor key with the given version, used from
+ * ecert epoch epoch on
+ */
+func putAuditorPublicKey(stub Wrapper, PKa *AuditorPublicKey, version int, epoch uint64) error {
+    PKaBytes, err := PKa.Bytes()
+    if err != nil {
+        return err
+    }
+    err = stub.PutState("auditor_pk", PKaBytes)
+    if err != nil {
+        return err
+    }
+    err = stub.PutState("auditor_pk_" + strconv.Itoa(version), PKaBytes)
+    if err != nil {
+        return err
+    }
+    err = stub.PutState("auditor_key_epoch_" + strconv.Itoa(version), []byte(strconv.FormatUint(epoch, 10)))
+    if err != nil {
+        return err
+    }
+    return stub.PutState("auditor_key_version", []byte(strconv.Itoa(version)))
+}
+
+/*
+ * RotateAuditorKey replaces the auditor key by a new one and advances the
+ * epoch, so every ecert epoch belongs to one auditor key. The auditor
+ * generates the new key and passes it with the re-encryption token in a
+ * RotateAuditorKeyRequest in the transient map, the chaincode never
+ * generates it. The pseudonyms in open de-anonymization requests are
+ * re-encrypted and rerandomized under the new key, the ocert registry is
+ * left to ReEncryptOCertRegistry and the key cannot be rotated again
+ * before it is done. Only the approvers of the de-anonymization policy
+ * can rotate the key. It returns an AuditorKeyReply with the new public
+ * key.
+ */
+func RotateAuditorKey(stub Wrapper, args [][]byte) ([]byte, error) {
+    requestBytes, err := transientRequest(stub, args)
+    if err != nil {
+        return nil, err
+    }
+    request := new(RotateAuditorKeyRequest)
+    err = request.SetBytes(requestBytes)
+    if err != nil {
+        return nil, err
+    }
+    policy, err := getDeanonPolicy(stub)
+    if err != nil {
+        return nil, err
+    }
+    approver, err := requireApprover(stub, policy)
+    if err != nil {
+        return nil, err
+    }
+    version, err := getAuditorKeyVersion(stub)
+    if err != nil {
+        return nil, err
+    }
+    pending, err := stub.GetState(OCertRegistryReEncryptionKey)
+    if err != nil {
+        return nil, err
+    }
+    if pending != nil {
+        return nil, fmt.Errorf("The ocert registry is still being re-encrypted to auditor key version %s", pending)
+    }
+    KPaOld, err := getAuditorKeypair(stub)
+    if err != nil {
+        return nil, err
+    }
+    if KPaOld == nil {
+        return nil, fmt.Errorf("No auditor key to rotate")
+    }
+    PKaOld := new(AuditorPublicKey)
+    PKaOld.PK = KPaOld.PK
+
+    PKa := new(AuditorPublicKey)
+    PKa.PK = request.PK
+    token := request.Token
+    if token == nil || token.From != version || token.To != version + 1 {
+        return nil, fmt.Errorf("Expecting a re-encryption token from auditor key version %d to %d", version, version + 1)
+    }
+    if !EVerifyReKeyToken(sharedParams, PKaOld, PKa, token) {
+        return nil, fmt.Errorf("The re-encryption token does not lead to the new auditor key")
+    }
+    KPa := new(AuditorKeypair)
+    KPa.PK = PKa.PK
+    if request.SK != nil {
+        pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+        g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+        PK := pairing.NewG1().MulZn(g1, pairing.NewZr().SetBytes(request.SK))
+        if !PK.Equals(pairing.NewG1().SetBytes(PKa.PK)) {
+            return nil, fmt.Errorf("The secret key does not match the new auditor key")
+        }
+        KPa.SK = request.SK
+    }
+
+    // Deterministic per transaction, so endorsements agree, and unknown
+    // to anyone without the token
+    rand := NewPRFReader(token.Delta, []byte("rotateAuditorKey"), []byte(stub.GetTxID()))
+
+    // Re-encrypt the de-anonymization requests that are not opened yet
+    value, err := stub.GetState("deanon_request_count")
+    if err != nil {
+        return nil, err
+    }
+    count := uint64(0)
+    if value != nil {
+        count, err = strconv.ParseUint(string(value), 10, 64)
+        if err != nil {
+            return nil, err
+        }
+    }
+    for id := uint64(1); id <= count; id++ {
+        record, err := getDeanonRecord(stub, []byte(strconv.FormatUint(id, 10)))
+        if err != nil {
+            return nil, err
+        }
+        if record.Status == DeanonOpened {
+            continue
+        }
+        record.P, _ = EReEncrypt(sharedParams, token, PKa, record.P, rand)
+        _, err = putDeanonRecord(stub, record, "rotate", approver)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    epoch, err := getEpoch(stub)
+    if err != nil {
+        return nil, err
+    }
+    epoch = NewEpoch(sharedParams, epoch.Epoch + 1)
+    err = putEpoch(stub, epoch)
+    if err != nil {
+        return nil, err
+    }
+    err = putAuditorPublicKey(stub, PKa, version + 1, epoch.Epoch)
+    if err != nil {
+        return nil, err
+    }
+    err = putReKeyToken(stub, version, token)
+    if err != nil {
+        return nil, err
+    }
+    err = stub.PutState(OCertRegistryReEncryptionKey, []byte(strconv.Itoa(version + 1)))
+    if err != nil {
+        return nil, err
+    }
+    fmt.Printf("[Ocert Scheme] [RotateAuditorKey] version: %d auditor_pk: ", version + 1)
+    fmt.Println(PKa)
+
+    // Without SK the old secret key is dropped as well, it would give the
+    // new one with the token
+    err = putAuditorKeypair(stub, KPa)
+    if err != nil {
+        return nil, err
+    }
+    reply := new(AuditorKeyReply)
+    reply.PK = PKa.PK
+    return reply.Bytes()
+}
+
+/*
+ * ReEncryptOCertRegistry re-encrypts and rerandomizes the next page of
+ * ocert registry records that are still under the auditor key before the
+ * last rotation, args is the optional page size, OCertRegistryPageSize by
+ * default. Only the approvers of the de-anonymization policy can call it,
+ * and only on peers of the auditor collection, which holds the
+ * re-encryption token. It returns a ReEncryptionProgress, once it is Done
+ * the registry can be traced again.
+ */
+func ReEncryptOCertRegistry(stub Wrapper, args [][]byte) ([]byte, error) {
+    if len(args) > 1 {
+        return nil, fmt.Errorf("Incorrect arguments. Expecting an optional page size")
+    }
+    pageSize := OCertRegistryPageSize
+    if len(args) == 1 {
+        size, err := strconv.Atoi(string(args[0]))
+        if err != nil || size <= 0 {
+            return nil, fmt.Errorf("Invalid page size: %s", args[0])
+        }
+        pageSize = size
+    }
+    policy, err := getDeanonPolicy(stub)
+    if err != nil {
+        return nil, err
+    }
+    _, err = requireApprover(stub, policy)
+    if err != nil {
+        return nil, err
+    }
+    pending, err := stub.GetState(OCertRegistryReEncryptionKey)
+    if err != nil {
+        return nil, err
+    }
+    if pending == nil {
+        return nil, fmt.Errorf("The ocert registry is not being re-encrypted")
+    }
+    version, err := strconv.Atoi(string(pending))
+    if err != nil {
+        return nil, err
+    }
+    token, err := getReKeyToken(stub, version - 1)
+    if err != nil {
+        return nil, err
+    }
+    PKa, err := getAuditorPublicKey(stub)
+    if err != nil {
+        return nil, err
+    }
+    rand := NewPRFReader(token.Delta, []byte("reEncryptOCertRegistry"), []byte(stub.GetTxID()))
+
+    iterator, err := stub.GetStateByPartialCompositeKey(OCertRegistryObjectType, []string{})
+    if err != nil {
+        return nil, err
+    }
+    defer iterator.Close()
+
+    progress := new(ReEncryptionProgress)
+    progress.Version = version
+    progress.Done = true
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, err
+        }
+        record := new(OCertRecord)
+        err = record.SetBytes(kv.Value)
+        if err != nil {
+            return nil, fmt.Errorf("Invalid registry entry %q: %s", kv.Key, err)
+        }
+        if record.KeyVersion >= version {
+            continue
+        }
+        if progress.ReEncrypted == pageSize {
+            progress.Done = false
+            break
+        }
+        record.P, _ = EReEncrypt(sharedParams, token, PKa, record.P, rand)
+        record.KeyVersion = version
+        err = putOCertRecord(stub, record)
+        if err != nil {
+            return nil, err
+        }
+        progress.ReEncrypted++
+    }
+    if progress.Done {
+        err = stub.DelState(OCertRegistryReEncryptionKey)
+        if err != nil {
+            return nil, err
+        }
+    }
+    fmt.Printf("[Ocert Scheme] [ReEncryptOCertRegistry] version: %d re-encrypted: %d done: %t\n", version, progress.ReEncrypted, progress.Done)
+    return progress.Bytes()
+}
+
+/*
+ * ReissueECert re-encrypts the pseudonym of an ecert from the last epoch
+ * of an older auditor key version under the current key, and signs a new
+ * ecert for the current epoch. Ecerts of earlier epochs have expired and
+ * are not re-issued. The reply proves that the new pseudonym encrypts the
+ * same id as the old one.
+ */
+func ReissueECert(stub Wrapper, args [][]byte) ([]byte, error) {
+    requestBytes, err := transientRequest(stub, args)
+    if err != nil {
+        return nil, err
+    }
+    request := new(ReissueECertRequest)
+    err = request.SetBytes(requestBytes)
+    if err != nil {
+        return nil, err
+    }
+
+    P := new(Pseudonym)
+    err = P.SetBytes(request.P)
+    if err != nil {
+        return nil, err
+    }
+    PKc := new(ClientPublicKey)
+    PKc.PK = request.PKc
+    ecert := new(Ecert)
+    err = ecert.SetBytes(request.Ecert)
+    if err != nil {
+        return nil, err
+    }
+
+    // The key version whose last epoch is the epoch of the ecert
+    version, err := getAuditorKeyVersion(stub)
+    if err != nil {
+        return nil, err
+    }
+    keyVersion := -1
+    for v := 0; v < version; v++ {
+        next, err := getAuditorKeyEpoch(stub, v + 1)
+        if err != nil {
+            return nil, err
+        }
+        if next == request.Epoch + 1 {
+            keyVersion = v
+        }
+    }
+    if keyVersion < 0 {
+        return nil, fmt.Errorf("Cannot re-issue ecerts of epoch %d", request.Epoch)
+    }
+    c, err := proofConstants(stub, request.VKVersion)
+    if err != nil {
+        return nil, err
+    }
+    oldScheme, err := GetSPSScheme(c.VK.Scheme)
+    if err != nil {
+        return nil, err
+    }
+    if !oldScheme.Verify(sharedParams, c.VK, P, PKc, NewEpoch(sharedParams, request.Epoch), ecert) {
+        return nil, fmt.Errorf("Invalid ecert for epoch %d", request.Epoch)
+    }
+
+    epoch, err := getEpoch(stub)
+    if err != nil {
+        return nil, err
+    }
+    token, err := getReKeyToken(stub, keyVersion)
+    if err != nil {
+        return nil, err
+    }
+    for v := keyVersion + 1; v < version; v++ {
+        next, err := getReKeyToken(stub, v)
+        if err != nil {
+            return nil, err
+        }
+        token, err = ECombineReKeyTokens(sharedParams, token, next)
+        if err != nil {
+            return nil, err
+        }
+    }
+    SKei, err := issuerSigningKey(stub)
+    if err != nil {
+        return nil, err
+    }
+    requestBytes, err = request.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    pseudonymRand, err := txRandomness(stub, "reissueECert", requestBytes, "pseudonym")
+    if err != nil {
+        return nil, err
+    }
+    ecertRand, err := txRandomness(stub, "reissueECert", requestBytes, "ecert")
+    if err != nil {
+        return nil, err
+    }
+    PKa, err := getAuditorPublicKey(stub)
+    if err != nil {
+        return nil, err
+    }
+    PPrime, proof := EReEncrypt(sharedParams, token, PKa, P, pseudonymRand)
+    scheme, err := GetSPSScheme(SKei.Scheme)
+    if err != nil {
+        return nil, err
+    }
+    newEcert := scheme.Sign(sharedParams, SKei, PPrime, PKc, epoch, ecertRand)
+    fmt.Printf("[Ocert Scheme] [ReissueECert] P: ")
+    fmt.Println(PPrime)
+
+    reply := new(GenECertReply)
+    reply.P, err = PPrime.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    reply.Ecert, err = newEcert.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    reply.Epoch, err = epoch.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    reply.ReEncProof = proof
+    reply.VKVersion, err = getSVKVersion(stub)
+    if err != nil {
+        return nil, err
+    }
+    err = emitEvent(stub, &OCertEvent{Type: EventECertIssued, VKVersion: reply.VKVersion, Epoch: epoch.Epoch})
+    if err != nil {
+        return nil, err
+    }
+    return reply.Bytes()
+}
+
+func getSVKVersion(stub Wrapper) (int, error) {
+    value, err := stub.GetState("structure_preserving_vk_version")
+    if err != nil {
+        return 0, err
+    }
+    if value == nil {
+        return 0, fmt.Errorf("Asset not found: structure_preserving_vk_version")
+    }
+    return strconv.Atoi(string(value))
+}
+
+func getSVKRecord(stub Wrapper, version int) (*SVKVersion, error) {
+    key := "structure_preserving_vk_" + strconv.Itoa(version)
+    value, err := stub.GetState(key)
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, fmt.Errorf("Asset not found: %s", key)
+    }
+    record := new(SVKVersion)
+    err = record.SetBytes(value)
+    if err != nil {
+        return nil, err
+    }
+    return record, nil
+}
+
+func putSVKRecord(stub Wrapper, record *SVKVersion) error {
+    value, err := record.Bytes()
+    if err != nil {
+        return err
+    }
+    return stub.PutState("structure_preserving_vk_" + strconv.Itoa(record.Version), value)
+}
+
+/*
+ * Store VK as the current verification key with the given version, and
+ * return its record, which cannot be read back in the same transaction
+ */
+func putSVK(stub Wrapper, VK *SVerificationKey, version int) (*SVKVersion, error) {
+    record := new(SVKVersion)
+    record.Version = version
+    record.VK = VK
+    err := putSVKRecord(stub, record)
+    if err != nil {
+        return nil, err
+    }
+    SVKb, err := VK.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    err = stub.PutState("structure_preserving_vk", SVKb)
+    if err != nil {
+        return nil, err
+    }
+    err = stub.PutState("structure_preserving_vk_version", []byte(strconv.Itoa(version)))
+    if err != nil {
+        return nil, err
+    }
+    return record, nil
+}
+
+/*
+ * The proof constants for ecerts signed with verification key version,
+ * which must be the current version or in its grace period. The auditor
+ * key is the current one on the ledger, the proof is for an ecert of the
+ * current epoch, which belongs to it.
+ */
+func proofConstants(stub Wrapper, version int) (*ProofConstants, error) {
+    record, err := getSVKRecord(stub, version)
+    if err != nil {
+        return nil, err
+    }
+    if record.NotAfter != 0 {
+        ts, err := stub.GetTxTimestamp()
+        if err != nil {
+            return nil, err
+        }
+        if ts.Seconds > record.NotAfter {
+            return nil, fmt.Errorf("Issuer key version %d expired at %s, refresh the ecert",
+                version, time.Unix(record.NotAfter, 0))
+        }
+    }
+
+    PKa, err := getAuditorPublicKey(stub)
+    if err != nil {
+        return nil, err
+    }
+
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    G := pairing.NewG1().SetBytes(sharedParams.G1)
+    H := pairing.NewG2().SetBytes(sharedParams.G2)
+    c := new(ProofConstants)
+    c.VK = record.VK
+    c.PKa = PKa
+    c.Egh = pairing.NewGT().Pair(G, H).Bytes()
+    c.Egz = SPSEgz(sharedParams, record.VK)
+    return c, nil
+}
+
+/*
+ * RotateIssuerKey generates a new structure preserving key pair to sign
+ * ecerts with. Proofs for ecerts of the old key are accepted for the
+ * grace period, given in seconds as the optional argument, SVKGracePeriod
+ * by default. Only the issuer may call it. It returns the new SVKVersion.
+ */
+func RotateIssuerKey(stub Wrapper, args [][]byte) ([]byte, error) {
+    if len(args) > 1 {
+        return nil, fmt.Errorf("Incorrect arguments. Expecting an optional grace period")
+    }
+    grace := SVKGracePeriod
+    if len(args) == 1 {
+        seconds, err := strconv.ParseInt(string(args[0]), 10, 64)
+        if err != nil || seconds < 0 {
+            return nil, fmt.Errorf("Invalid grace period: %s", args[0])
+        }
+        grace = time.Duration(seconds) * time.Second
+    }
+
+    err := requireIssuer(stub)
+    if err != nil {
+        return nil, err
+    }
+    version, err := getSVKVersion(stub)
+    if err != nil {
+        return nil, err
+    }
+    old, err := getSVKRecord(stub, version)
+    if err != nil {
+        return nil, err
+    }
+    ts, err := stub.GetTxTimestamp()
+    if err != nil {
+        return nil, err
+    }
+    old.NotAfter = time.Unix(ts.Seconds, 0).Add(grace).Unix()
+    err = putSVKRecord(stub, old)
+    if err != nil {
+        return nil, err
+    }
+
+    seed, err := getIssuerSeed(stub)
+    if err != nil {
+        return nil, err
+    }
+    scheme, err := getSPSScheme(stub)
+    if err != nil {
+        return nil, err
+    }
+    VKei, err := newIssuerKey(stub, seed, scheme, version + 1)
+    if err != nil {
+        return nil, err
+    }
+    record, err := putSVK(stub, VKei, version + 1)
+    if err != nil {
+        return nil, err
+    }
+    fmt.Printf("[Ocert Scheme] [RotateIssuerKey] version: %d sVK: ", version + 1)
+    fmt.Println(VKei)
+    return record.Bytes()
+}
+
+/*
+ * GetIssuerKeyStatus tells a client whose ecert was signed with the given
+ * verification key version whether it has to refresh the ecert, and
+ * until when the old version is accepted
+ */
+func GetIssuerKeyStatus(stub Wrapper, args [][]byte) ([]byte, error) {
+    if len(args) != 1 {
+        return nil, fmt.Errorf("Incorrect arguments. Expecting a verification key version")
+    }
+    version, err := strconv.Atoi(string(args[0]))
+    if err != nil {
+        return nil, fmt.Errorf("Invalid verification key version: %s", args[0])
+    }
+    record, err := getSVKRecord(stub, version)
+    if err != nil {
+        return nil, err
+    }
+    status := new(IssuerKeyStatus)
+    status.Current, err = getSVKVersion(stub)
+    if err != nil {
+        return nil, err
+    }
+    status.Version = version
+    status.NotAfter = record.NotAfter
+    status.Refresh = version != status.Current
+    return status.Bytes()
+}
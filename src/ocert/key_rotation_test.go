@@ -0,0 +1,319 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "reflect"
+    "testing"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * Issue an ecert and register ocerts, rotate the auditor key, re-encrypt
+ * the registry page by page and have the ecert re-issued for the
+ * re-encrypted pseudonym
+ */
+func TestAuditorKeyRotation(t *testing.T) {
+    stub := NewMemoryStub()
+    approver := []byte("approver")
+    policy := new(DeanonPolicy)
+    policy.Required = 1
+    policy.Approvers = []string{IdentityID(approver)}
+    policyBytes, err := policy.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    key, deliveryKey := newTestDeliveryKey()
+    KPab, err := Setup(stub, [][]byte{[]byte(""), nil, policyBytes, deliveryKey})
+    if err != nil {
+        t.Fatal(err)
+    }
+    KPaOld, err := openTestKeypair(key, KPab)
+    if err != nil {
+        t.Fatal(err)
+    }
+    PKaOld := new(AuditorPublicKey)
+    PKaOld.PK = KPaOld.PK
+
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    stub.Creator = newTestCreator("Org1MSP", "client")
+    request := new(GenECertRequest)
+    request.PKc = pairing.NewG2().Rand().Bytes()
+    requestBytes, err := request.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Transient = TransientRequest(requestBytes)
+    replyBytes, err := invokeTx(t, stub, 1, GenECert, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    reply := new(GenECertReply)
+    if err := reply.SetBytes(replyBytes); err != nil {
+        t.Fatal(err)
+    }
+    epoch := new(Epoch)
+    if err := epoch.SetBytes(reply.Epoch); err != nil {
+        t.Fatal(err)
+    }
+    clientID := NewClientID(sharedParams, reply.EnrollmentID)
+    for i := 0; i < 3; i++ {
+        stub.NextTx()
+        P, _ := ERerand(sharedParams, PKaOld, EEnc(sharedParams, PKaOld, clientID, nil), nil)
+        if err := registerOCert(stub, []byte{byte(i + 1)}, P, int64(i)); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    // The auditor generates the new key and the token itself
+    PKaNew, SKaNew := EKeyGen(sharedParams, nil)
+    SKaOld := new(AuditorSecretKey)
+    SKaOld.SK = KPaOld.SK
+    rotate := new(RotateAuditorKeyRequest)
+    rotate.PK = PKaNew.PK
+    rotate.Token = EReKeyToken(sharedParams, SKaOld, 0, SKaNew, 1)
+    rotate.SK = SKaNew.SK
+    rotations := []struct {
+        name string
+        creator []byte
+        change func(*RotateAuditorKeyRequest)
+    }{
+        {"Client", []byte("client"), func(*RotateAuditorKeyRequest) {}},
+        {"OtherKey", approver, func(r *RotateAuditorKeyRequest) { r.PK = pairing.NewG1().Rand().Bytes() }},
+        {"OtherVersion", approver, func(r *RotateAuditorKeyRequest) { r.Token = &ReKeyToken{1, 2, rotate.Token.Delta} }},
+        {"OtherSecretKey", approver, func(r *RotateAuditorKeyRequest) { r.SK = SKaOld.SK }},
+    }
+    for _, rotation := range rotations {
+        wrong := *rotate
+        rotation.change(&wrong)
+        wrongBytes, err := wrong.Bytes()
+        if err != nil {
+            t.Fatal(err)
+        }
+        stub.Creator = rotation.creator
+        stub.Transient = TransientRequest(wrongBytes)
+        if _, err := RotateAuditorKey(stub, nil); err == nil {
+            t.Errorf("%s: rotates the auditor key", rotation.name)
+        }
+    }
+
+    rotateBytes, err := rotate.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Creator = approver
+    stub.Transient = TransientRequest(rotateBytes)
+    rotateReplyBytes, err := invokeTx(t, stub, 0, RotateAuditorKey, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    rotateReply := new(AuditorKeyReply)
+    if err := rotateReply.SetBytes(rotateReplyBytes); err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(rotateReply.PK, PKaNew.PK) || rotateReply.Sealed != nil {
+        t.Errorf("rotation replied %s", rotateReplyBytes)
+    }
+
+    // The registry is re-encrypted in pages, and neither traced nor
+    // rotated again before it is done
+    clientIDBytes, err := clientID.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := invokeTx(t, stub, 0, TraceOCerts, [][]byte{clientIDBytes}); err == nil {
+        t.Error("traces a registry that is being re-encrypted")
+    }
+    PKaNext, SKaNext := EKeyGen(sharedParams, nil)
+    next := new(RotateAuditorKeyRequest)
+    next.PK = PKaNext.PK
+    next.Token = EReKeyToken(sharedParams, SKaNew, 1, SKaNext, 2)
+    next.SK = SKaNext.SK
+    nextBytes, err := next.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Transient = TransientRequest(nextBytes)
+    if _, err := invokeTx(t, stub, 0, RotateAuditorKey, nil); err == nil {
+        t.Error("rotates the auditor key before the registry is re-encrypted")
+    }
+    pages := []ReEncryptionProgress{{1, 2, false}, {1, 1, true}}
+    for i, expected := range pages {
+        progressBytes, err := invokeTx(t, stub, 0, ReEncryptOCertRegistry, [][]byte{[]byte("2")})
+        if err != nil {
+            t.Fatal(err)
+        }
+        progress := new(ReEncryptionProgress)
+        if err := progress.SetBytes(progressBytes); err != nil {
+            t.Fatal(err)
+        }
+        if *progress != expected {
+            t.Errorf("page %d: got %+v, want %+v", i, *progress, expected)
+        }
+    }
+    if _, err := invokeTx(t, stub, 0, ReEncryptOCertRegistry, nil); err == nil {
+        t.Error("re-encrypts a registry that is done")
+    }
+    records, err := GetOCertRegistry(stub)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, record := range records {
+        if record.KeyVersion != 1 || !reflect.DeepEqual(EDec(sharedParams, SKaNew, record.P), clientID) {
+            t.Errorf("record %s is not re-encrypted under the new key", record.TxID)
+        }
+    }
+
+    reissue := new(ReissueECertRequest)
+    reissue.P = reply.P
+    reissue.PKc = request.PKc
+    reissue.Ecert = reply.Ecert
+    reissue.Epoch = epoch.Epoch + 1
+    reissueBytes, err := reissue.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Transient = TransientRequest(reissueBytes)
+    if _, err := ReissueECert(stub, nil); err == nil {
+        t.Error("re-issues an ecert of the wrong epoch")
+    }
+
+    reissue.Epoch = epoch.Epoch
+    reissueBytes, err = reissue.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Transient = TransientRequest(reissueBytes)
+    newReplyBytes, err := invokeTx(t, stub, 1, ReissueECert, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    newReply := new(GenECertReply)
+    if err := newReply.SetBytes(newReplyBytes); err != nil {
+        t.Fatal(err)
+    }
+
+    P := new(Pseudonym)
+    P.SetBytes(reply.P)
+    newP := new(Pseudonym)
+    newP.SetBytes(newReply.P)
+    if !VerifyReEncryption(sharedParams, PKaOld, PKaNew, P, newP, newReply.ReEncProof) {
+        t.Error("invalid re-encryption proof")
+    }
+    id := NewClientID(sharedParams, reply.EnrollmentID)
+    if !reflect.DeepEqual(id, EDec(sharedParams, SKaNew, newP)) {
+        t.Error("re-encrypted pseudonym does not decrypt to the client id")
+    }
+
+    newEpoch := new(Epoch)
+    newEpoch.SetBytes(newReply.Epoch)
+    ecert := new(Ecert)
+    ecert.SetBytes(newReply.Ecert)
+    PKc := new(ClientPublicKey)
+    PKc.PK = request.PKc
+    if newEpoch.Epoch != epoch.Epoch + 1 {
+        t.Errorf("re-issued for epoch %d, want %d", newEpoch.Epoch, epoch.Epoch + 1)
+    }
+    record, err := getSVKRecord(stub, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !SVerify(sharedParams, record.VK, newP, PKc, newEpoch, ecert) {
+        t.Error("cannot verify the re-issued ecert")
+    }
+}
+
+/*
+ * Rotate the issuer key, which only the issuer may do, and check that
+ * the old verification key is accepted during its grace period only, and
+ * that clients of the old key are told to refresh their ecerts
+ */
+func TestIssuerKeyRotation(t *testing.T) {
+    stub := NewMemoryStub()
+    issuer := []byte("issuer")
+    stub.Creator = issuer
+    if _, err := Setup(stub, nil); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    oldRecord, err := getSVKRecord(stub, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    oldVK := oldRecord.VK
+
+    // Only the issuer rotates its key
+    stub.Creator = []byte("client")
+    if _, err := invokeTx(t, stub, 0, RotateIssuerKey, [][]byte{[]byte("3600")}); err == nil {
+        t.Fatal("a client rotates the issuer key")
+    }
+    stub.Creator = issuer
+    recordBytes, err := invokeTx(t, stub, 0, RotateIssuerKey, [][]byte{[]byte("3600")})
+    if err != nil {
+        t.Fatal(err)
+    }
+    record := new(SVKVersion)
+    err = record.SetBytes(recordBytes)
+    if err != nil || record.Version != 1 {
+        t.Fatalf("rotated to version %d: %v", record.Version, err)
+    }
+    current := new(SVerificationKey)
+    if err := current.SetBytes(stub.State["structure_preserving_vk"]); err != nil || !current.Equals(record.VK) {
+        t.Fatalf("the rotated key is not the current key: %v", err)
+    }
+
+    c, err := proofConstants(stub, 0)
+    if err != nil || !c.VK.Equals(oldVK) {
+        t.Errorf("old key in grace period: %v", err)
+    }
+    statusBytes, err := GetIssuerKeyStatus(stub, [][]byte{[]byte("0")})
+    if err != nil {
+        t.Fatal(err)
+    }
+    status := new(IssuerKeyStatus)
+    err = status.SetBytes(statusBytes)
+    if err != nil || !status.Refresh || status.Current != 1 || status.NotAfter == 0 {
+        t.Errorf("status of version 0 %v: %v", status, err)
+    }
+
+    // The grace period is over
+    old, err := getSVKRecord(stub, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    old.NotAfter = 1
+    if err := putSVKRecord(stub, old); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    if _, err := proofConstants(stub, 0); err == nil {
+        t.Error("accepts the old key after its grace period")
+    }
+
+    c, err = proofConstants(stub, 1)
+    if err != nil || !c.VK.Equals(record.VK) {
+        t.Errorf("current key: %v", err)
+    }
+    statusBytes, err = GetIssuerKeyStatus(stub, [][]byte{[]byte("1")})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := status.SetBytes(statusBytes); err != nil || status.Refresh {
+        t.Errorf("status of version 1 %v: %v", status, err)
+    }
+}
@@ -0,0 +1,322 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * An in-memory Wrapper for tests and benchmarks. The fields that a peer
+ * would fill in for a transaction (creator, transient map, tx id and
+ * timestamp) are set by the caller, and events are recorded in Events.
+ * As on a peer, a transaction has at most one event, the last one set,
+ * and it does not read its own writes: they are pending until Commit or
+ * NextTx commits them, or Discard drops them as for a failed transaction.
+ * Private data collections are in Private, a collection in NotMember is
+ * one the simulated peer is not a member of, and cannot read.
+ */
+
+package ocert
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+    "unicode/utf8"
+    "github.com/golang/protobuf/ptypes/timestamp"
+    "github.com/hyperledger/fabric/core/chaincode/shim"
+    "github.com/hyperledger/fabric/protos/ledger/queryresult"
+)
+
+// As in the shim: composite keys start with compositeKeyNamespace, and
+// each of their parts ends with U+0000
+const (
+    compositeKeyNamespace = "\x00"
+    compositeKeySeparator = "\x00"
+)
+
+type MemoryEvent struct {
+    TxID     string
+    Name     string
+    Payload  []byte
+    Replaced int // earlier events of the transaction that this one replaced
+}
+
+type MemoryStub struct {
+    State     map[string][]byte
+    Private   map[string]map[string][]byte
+    NotMember map[string]bool
+    Creator   []byte
+    Transient map[string][]byte
+    TxID      string
+    Timestamp *timestamp.Timestamp // nil is the current time
+    Events    []*MemoryEvent
+
+    // Writes of the current transaction, a nil value deletes the key
+    pending        map[string][]byte
+    pendingPrivate map[string]map[string][]byte
+}
+
+func NewMemoryStub() *MemoryStub {
+    stub := new(MemoryStub)
+    stub.State = make(map[string][]byte)
+    stub.Private = make(map[string]map[string][]byte)
+    stub.NotMember = make(map[string]bool)
+    stub.Transient = make(map[string][]byte)
+    stub.NextTx()
+    return stub
+}
+
+/*
+ * Commit the writes of the current transaction and start a new one with
+ * a fresh tx id. The creator, transient map and timestamp are kept, set
+ * them for the next transaction if needed.
+ */
+func (stub *MemoryStub) NextTx() {
+    stub.Commit()
+    id := make([]byte, 32)
+    rand.Read(id)
+    stub.TxID = hex.EncodeToString(id)
+}
+
+/*
+ * Commit the writes of the current transaction, so later reads see them
+ */
+func (stub *MemoryStub) Commit() {
+    for key, value := range stub.pending {
+        if value == nil {
+            delete(stub.State, key)
+        } else {
+            stub.State[key] = value
+        }
+    }
+    for collection, writes := range stub.pendingPrivate {
+        if stub.Private[collection] == nil {
+            stub.Private[collection] = make(map[string][]byte)
+        }
+        for key, value := range writes {
+            if value == nil {
+                delete(stub.Private[collection], key)
+            } else {
+                stub.Private[collection][key] = value
+            }
+        }
+    }
+    stub.Discard()
+}
+
+/*
+ * Drop the writes of the current transaction
+ */
+func (stub *MemoryStub) Discard() {
+    stub.pending = make(map[string][]byte)
+    stub.pendingPrivate = make(map[string]map[string][]byte)
+}
+
+func (stub *MemoryStub) GetState(key string) ([]byte, error) {
+    return stub.State[key], nil
+}
+
+func (stub *MemoryStub) PutState(key string, value []byte) error {
+    if key == "" {
+        return fmt.Errorf("Empty key")
+    }
+    if value == nil {
+        value = []byte{}
+    }
+    stub.pending[key] = value
+    return nil
+}
+
+func (stub *MemoryStub) DelState(key string) error {
+    stub.pending[key] = nil
+    return nil
+}
+
+func (stub *MemoryStub) GetPrivateData(collection, key string) ([]byte, error) {
+    if stub.NotMember[collection] {
+        return nil, fmt.Errorf("Peer is not a member of collection %s", collection)
+    }
+    return stub.Private[collection][key], nil
+}
+
+/*
+ * Writes are allowed on any peer, as endorsers of a collection need not
+ * be members of it
+ */
+func (stub *MemoryStub) PutPrivateData(collection string, key string, value []byte) error {
+    if key == "" {
+        return fmt.Errorf("Empty key")
+    }
+    if value == nil {
+        value = []byte{}
+    }
+    if stub.pendingPrivate[collection] == nil {
+        stub.pendingPrivate[collection] = make(map[string][]byte)
+    }
+    stub.pendingPrivate[collection][key] = value
+    return nil
+}
+
+func (stub *MemoryStub) DelPrivateData(collection, key string) error {
+    if stub.pendingPrivate[collection] == nil {
+        stub.pendingPrivate[collection] = make(map[string][]byte)
+    }
+    stub.pendingPrivate[collection][key] = nil
+    return nil
+}
+
+/*
+ * The simple keys in [startKey, endKey), in order. An empty endKey has
+ * no upper bound.
+ */
+func (stub *MemoryStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+    if strings.HasPrefix(startKey, compositeKeyNamespace) || strings.HasPrefix(endKey, compositeKeyNamespace) {
+        return nil, fmt.Errorf("Range queries take simple keys, not composite keys")
+    }
+    return stub.iterate(func(key string) bool {
+        return !strings.HasPrefix(key, compositeKeyNamespace) &&
+               key >= startKey && (endKey == "" || key < endKey)
+    }), nil
+}
+
+/*
+ * The composite keys of objectType whose first attributes are keys
+ */
+func (stub *MemoryStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+    prefix, err := stub.CreateCompositeKey(objectType, keys)
+    if err != nil {
+        return nil, err
+    }
+    return stub.iterate(func(key string) bool {
+        return strings.HasPrefix(key, prefix)
+    }), nil
+}
+
+func (stub *MemoryStub) iterate(match func(key string) bool) *memoryIterator {
+    keys := make([]string, 0)
+    for key := range stub.State {
+        if match(key) {
+            keys = append(keys, key)
+        }
+    }
+    sort.Strings(keys)
+    iterator := new(memoryIterator)
+    for _, key := range keys {
+        iterator.kvs = append(iterator.kvs, &queryresult.KV{Key: key, Value: stub.State[key]})
+    }
+    return iterator
+}
+
+func (stub *MemoryStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+    err := validateCompositeKeyAttribute(objectType)
+    if err != nil {
+        return "", err
+    }
+    key := compositeKeyNamespace + objectType + compositeKeySeparator
+    for _, attribute := range attributes {
+        err = validateCompositeKeyAttribute(attribute)
+        if err != nil {
+            return "", err
+        }
+        key += attribute + compositeKeySeparator
+    }
+    return key, nil
+}
+
+func (stub *MemoryStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+    if !strings.HasPrefix(compositeKey, compositeKeyNamespace) {
+        return "", nil, fmt.Errorf("Not a composite key: %s", compositeKey)
+    }
+    parts := strings.Split(compositeKey[len(compositeKeyNamespace):], compositeKeySeparator)
+    if len(parts) < 2 {
+        return "", nil, fmt.Errorf("Not a composite key: %s", compositeKey)
+    }
+    // The key ends with a separator, the last part is empty
+    return parts[0], parts[1:len(parts) - 1], nil
+}
+
+func validateCompositeKeyAttribute(attribute string) error {
+    if !utf8.ValidString(attribute) {
+        return fmt.Errorf("Not a valid utf8 string: %x", attribute)
+    }
+    for _, r := range attribute {
+        if r == 0 || r == utf8.MaxRune {
+            return fmt.Errorf("Input contains unicode %#U starting at position [%d]", r, strings.IndexRune(attribute, r))
+        }
+    }
+    return nil
+}
+
+func (stub *MemoryStub) GetTxID() string {
+    return stub.TxID
+}
+
+func (stub *MemoryStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+    if stub.Timestamp != nil {
+        return stub.Timestamp, nil
+    }
+    now := time.Now()
+    return &timestamp.Timestamp{Seconds: now.Unix(), Nanos: int32(now.Nanosecond())}, nil
+}
+
+func (stub *MemoryStub) GetCreator() ([]byte, error) {
+    return stub.Creator, nil
+}
+
+func (stub *MemoryStub) GetTransient() (map[string][]byte, error) {
+    return stub.Transient, nil
+}
+
+func (stub *MemoryStub) SetEvent(name string, payload []byte) error {
+    if name == "" {
+        return fmt.Errorf("Event name can not be nil string")
+    }
+    event := &MemoryEvent{TxID: stub.TxID, Name: name, Payload: payload}
+    if last := len(stub.Events) - 1; last >= 0 && stub.Events[last].TxID == stub.TxID {
+        event.Replaced = stub.Events[last].Replaced + 1
+        stub.Events[last] = event
+        return nil
+    }
+    stub.Events = append(stub.Events, event)
+    return nil
+}
+
+/*
+ * Iterator over a snapshot of the matching keys
+ */
+type memoryIterator struct {
+    kvs  []*queryresult.KV
+    next int
+}
+
+func (iterator *memoryIterator) HasNext() bool {
+    return iterator.next < len(iterator.kvs)
+}
+
+func (iterator *memoryIterator) Next() (*queryresult.KV, error) {
+    if !iterator.HasNext() {
+        return nil, fmt.Errorf("No more results")
+    }
+    kv := iterator.kvs[iterator.next]
+    iterator.next++
+    return kv, nil
+}
+
+func (iterator *memoryIterator) Close() error {
+    return nil
+}
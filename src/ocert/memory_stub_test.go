@@ -0,0 +1,188 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "testing"
+    "reflect"
+    "fmt"
+    "github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func iteratorKeys(iterator shim.StateQueryIteratorInterface) []string {
+    keys := make([]string, 0)
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil
+        }
+        keys = append(keys, kv.Key)
+    }
+    iterator.Close()
+    return keys
+}
+
+/*
+ * Run call as a new transaction and check that, if it succeeds, it sets
+ * exactly the given number of events. The writes of a successful call are
+ * committed, those of a failed one dropped.
+ */
+func invokeTx(t *testing.T, stub *MemoryStub, events int, call func(Wrapper, [][]byte) ([]byte, error), args [][]byte) ([]byte, error) {
+    t.Helper()
+    stub.NextTx()
+    reply, err := call(stub, args)
+    if err != nil {
+        stub.Discard()
+        return reply, err
+    }
+    stub.Commit()
+    emitted := 0
+    for _, event := range stub.Events {
+        if event.TxID == stub.TxID {
+            emitted += 1 + event.Replaced
+        }
+    }
+    if emitted != events {
+        t.Errorf("transaction set %d events, want %d", emitted, events)
+    }
+    return reply, err
+}
+
+/*
+ * Only the last event set in a transaction is kept
+ */
+func TestMemoryStubEvents(t *testing.T) {
+    stub := NewMemoryStub()
+    for i := 0; i < 3; i++ {
+        stub.SetEvent("event", []byte(fmt.Sprint(i)))
+    }
+    first := stub.TxID
+    stub.NextTx()
+    stub.SetEvent("event", []byte("3"))
+    if stub.SetEvent("", nil) == nil {
+        t.Error("event without a name accepted")
+    }
+
+    if len(stub.Events) != 2 {
+        t.Fatalf("got %d events, want 2", len(stub.Events))
+    }
+    if event := stub.Events[0]; event.TxID != first || string(event.Payload) != "2" || event.Replaced != 2 {
+        t.Errorf("first transaction kept %+v", event)
+    }
+    if event := stub.Events[1]; event.TxID != stub.TxID || string(event.Payload) != "3" || event.Replaced != 0 {
+        t.Errorf("second transaction kept %+v", event)
+    }
+}
+
+/*
+ * Simple and composite keys, range queries over each of them, and
+ * deletion
+ */
+func TestMemoryStubQueries(t *testing.T) {
+    stub := NewMemoryStub()
+    for _, key := range []string{"b", "a", "d", "c"} {
+        stub.PutState(key, []byte(key))
+    }
+    var composites []string
+    for _, attributes := range [][]string{{"org1", "alice"}, {"org1", "bob"}, {"org2", "alice"}} {
+        key, err := stub.CreateCompositeKey("client", attributes)
+        if err != nil {
+            t.Fatal(err)
+        }
+        objectType, split, err := stub.SplitCompositeKey(key)
+        if err != nil || objectType != "client" || !reflect.DeepEqual(split, attributes) {
+            t.Fatalf("split %q into %q %q: %v", key, objectType, split, err)
+        }
+        stub.PutState(key, []byte(attributes[1]))
+        composites = append(composites, key)
+    }
+    if _, err := stub.CreateCompositeKey("client", []string{"a\x00b"}); err == nil {
+        t.Error("accepts an attribute with a null byte")
+    }
+    stub.NextTx()
+
+    ranges := []struct {
+        name string
+        start, end string
+        keys []string
+    }{
+        {"Range", "b", "d", []string{"b", "c"}},
+        {"All", "", "", []string{"a", "b", "c", "d"}},
+    }
+    for _, tc := range ranges {
+        iterator, err := stub.GetStateByRange(tc.start, tc.end)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if keys := iteratorKeys(iterator); !reflect.DeepEqual(keys, tc.keys) {
+            t.Errorf("%s: got %q, want %q", tc.name, keys, tc.keys)
+        }
+    }
+    iterator, err := stub.GetStateByPartialCompositeKey("client", []string{"org1"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if keys := iteratorKeys(iterator); !reflect.DeepEqual(keys, composites[:2]) {
+        t.Errorf("partial: got %q, want %q", keys, composites[:2])
+    }
+
+    stub.DelState("c")
+    stub.NextTx()
+    value, err := stub.GetState("c")
+    if err != nil || value != nil {
+        t.Errorf("deleted key has value %q: %v", value, err)
+    }
+}
+
+/*
+ * As on a peer, a transaction does not read its own writes, and the
+ * writes of a failed transaction are dropped
+ */
+func TestMemoryStubPendingWrites(t *testing.T) {
+    stub := NewMemoryStub()
+    stub.PutState("key", []byte("value"))
+    stub.PutPrivateData("collection", "key", []byte("secret"))
+    if value, _ := stub.GetState("key"); value != nil {
+        t.Error("reads an uncommitted write")
+    }
+    if value, _ := stub.GetPrivateData("collection", "key"); value != nil {
+        t.Error("reads an uncommitted private write")
+    }
+    stub.NextTx()
+    if value, _ := stub.GetState("key"); string(value) != "value" {
+        t.Errorf("committed %q", value)
+    }
+    if value, _ := stub.GetPrivateData("collection", "key"); string(value) != "secret" {
+        t.Errorf("committed private %q", value)
+    }
+
+    stub.DelState("key")
+    if value, _ := stub.GetState("key"); value == nil {
+        t.Error("reads an uncommitted deletion")
+    }
+    stub.Discard()
+    stub.NextTx()
+    if value, _ := stub.GetState("key"); value == nil {
+        t.Error("a dropped deletion is committed")
+    }
+    stub.DelState("key")
+    stub.Commit()
+    if value, _ := stub.GetState("key"); value != nil {
+        t.Error("deletion not committed")
+    }
+}
@@ -26,40 +26,39 @@
  *  - Get
  *  - GetSharedParams
  *  - GetAuditorKeypair
+ *  - AdvanceEpoch
  */
 
 package ocert
 
 import (
     "fmt"
-    "crypto"
-    "crypto/rsa"
-    "crypto/rand"
-    "crypto/sha256"
+    "bytes"
     "crypto/x509"
-    "math/big"
+    "crypto/ecdsa"
     "time"
-    "os"
-    "github.com/Nik-U/pbc"
+    "io"
+    "io/ioutil"
 )
 
 /*
- * The private key used in structure preserving scheme should keep in memory,
- * not publicly on blockchain.
+ * The private key used in structure preserving scheme is not kept publicly
+ * on blockchain, it is in the issuer collection with the issuer seed, see
+ * issuer_secrets.go.
  */
 var sharedParams *SharedParams
-var sSigningKey *SSigningKey
-var rsaPrivateKey *rsa.PrivateKey
-var serialNumber *big.Int
-var auditorKeypair []byte
-var consts *ProofConstants
-
-var verifyProofLog *os.File
 
+/*
+ * Where GenOCert records the proof verification time. The benchmark
+ * chaincode points it at a file under /data, everything else discards it.
+ */
+var verifyProofLog io.Writer = ioutil.Discard
 
-func getSerialNumber() (*big.Int) {
-    serialNumber.Add(serialNumber, big.NewInt(1))
-    return serialNumber
+func SetVerifyProofLog(w io.Writer) {
+    if w == nil {
+        w = ioutil.Discard
+    }
+    verifyProofLog = w
 }
 
 func Get(stub Wrapper, args [][]byte) ([]byte, error) {
@@ -88,17 +87,158 @@ func GetSharedParams(stub Wrapper, args [][]byte) ([]byte, error) {
     return value, nil
 }
 
+/*
+ * Returns the auditor keypair sealed to the delivery key, so anyone may
+ * fetch it but only the auditor can open it
+ */
 func GetAuditorKeypair(stub Wrapper, args [][]byte)([]byte, error) {
     if len(args) != 0 {
         return nil, fmt.Errorf("Incorrect arguments. Expecting no arguments")
     }
 
-    // TODO We are cheat here, we should verify the request is from the 
-    // auditor
-    if string(auditorKeypair) == "NoAuditorKeyPair" {
+    KPa, err := getAuditorKeypair(stub)
+    if err != nil {
+        return nil, err
+    }
+    if KPa == nil {
         return nil, fmt.Errorf("NoAuditorKeyPair")
     }
-    return auditorKeypair, nil
+    recipient, err := getDeliveryKey(stub)
+    if err != nil {
+        return nil, err
+    }
+    if recipient == nil {
+        return nil, fmt.Errorf("No auditor delivery key, the auditor keypair is not delivered")
+    }
+    return auditorKeyReply(recipient, KPa)
+}
+
+/*
+ * Reads the current validity epoch of ecerts from the ledger
+ */
+func getEpoch(stub Wrapper) (*Epoch, error) {
+    value, err := stub.GetState("ecert_epoch")
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, fmt.Errorf("Asset not found: ecert_epoch")
+    }
+    epoch := new(Epoch)
+    err = epoch.SetBytes(value)
+    if err != nil {
+        return nil, err
+    }
+    return epoch, nil
+}
+
+func putEpoch(stub Wrapper, epoch *Epoch) error {
+    epochBytes, err := epoch.Bytes()
+    if err != nil {
+        return err
+    }
+    return stub.PutState("ecert_epoch", epochBytes)
+}
+
+/*
+ * Reads the public key of the ocert signer from the ledger
+ */
+func getOCertPK(stub Wrapper) (*OCertPK, error) {
+    value, err := stub.GetState("ocert_pk")
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, fmt.Errorf("Asset not found: ocert_pk")
+    }
+    pk := new(OCertPK)
+    err = pk.SetBytes(value)
+    if err != nil {
+        return nil, err
+    }
+    return pk, nil
+}
+
+/*
+ * Reads the issuer certificate from the ledger, nil if the ocert signer
+ * cannot be used in X.509
+ */
+func getIssuerCertificate(stub Wrapper) (*x509.Certificate, error) {
+    value, err := stub.GetState("issuer_cert")
+    if err != nil {
+        return nil, err
+    }
+    if value == nil {
+        return nil, nil
+    }
+    return x509.ParseCertificate(value)
+}
+
+/*
+ * The issuer is the identity that called Setup, see IdentityID. Without
+ * a caller identity at Setup there is no issuer, and requests only the
+ * issuer may make are refused.
+ */
+func putIssuerIdentity(stub Wrapper) error {
+    creator, err := stub.GetCreator()
+    if err != nil {
+        return err
+    }
+    if len(creator) == 0 {
+        return nil
+    }
+    return stub.PutState("issuer_identity", []byte(IdentityID(creator)))
+}
+
+/*
+ * Check that the caller is the issuer
+ */
+func requireIssuer(stub Wrapper) error {
+    caller, err := callerID(stub)
+    if err != nil {
+        return err
+    }
+    issuer, err := stub.GetState("issuer_identity")
+    if err != nil {
+        return err
+    }
+    if issuer == nil {
+        return fmt.Errorf("No issuer identity")
+    }
+    if string(issuer) != caller {
+        return fmt.Errorf("%s is not the issuer", caller)
+    }
+    return nil
+}
+
+/*
+ * AdvanceEpoch moves the validity epoch of ecerts forward by one. Ecerts
+ * signed for an earlier epoch are rejected by GenOCert afterwards, so
+ * clients have to request a new ecert. Only the issuer may call it. It
+ * returns the new epoch.
+ */
+func AdvanceEpoch(stub Wrapper, args [][]byte) ([]byte, error) {
+    if len(args) != 0 {
+        return nil, fmt.Errorf("Incorrect arguments. Expecting no arguments")
+    }
+
+    err := requireIssuer(stub)
+    if err != nil {
+        return nil, err
+    }
+    epoch, err := getEpoch(stub)
+    if err != nil {
+        return nil, err
+    }
+    epoch = NewEpoch(sharedParams, epoch.Epoch + 1)
+    fmt.Printf("[Ocert Scheme] [AdvanceEpoch] epoch: ")
+    fmt.Println(epoch.Epoch)
+
+    err = putEpoch(stub, epoch)
+    if err != nil {
+        return nil, err
+    }
+    return epoch.Bytes()
 }
 
 /*
@@ -106,120 +246,236 @@ func GetAuditorKeypair(stub Wrapper, args [][]byte)([]byte, error) {
  * It generates 3 keypairs.
  *  1. Auditor's key pair (from rerandomization scheme)
  *  2. Key pair to generate ecert (from structure preserving scheme)
- *  3. Key pair to generate ocert (from RSA)
- * All public keys are stored in blockchain, while the private
- * keys are in memory. It returns the Auditor's keypair to the auditor
+ *  3. Key pair to generate ocert (from the OCertSigner named by the
+ *     optional argument, RSA PKCS#1 v1.5 by default)
+ * All public keys are stored in blockchain. The issuer private keys are
+ * derived from the issuer seed and kept in the issuer collection (see
+ * issuer_secrets.go), the auditor's keypair in the private data
+ * collection of the auditor (see auditor_secrets.go). It
+ * returns the Auditor's public key, and the keypair sealed to the
+ * delivery key of the auditor (the optional fourth argument, see
+ * key_delivery.go), never the plaintext secret key.
+ * If a threshold auditor public key from EDKGPublicKey is given as the
+ * second argument, the auditor's key pair is not generated and there is
+ * no secret key to deliver. The optional third argument is the
+ * DeanonPolicy, without it pseudonyms cannot be opened on chain. The
+ * optional fifth argument names the SPSScheme of the issuer keys, AGHO by
+ * default, and is kept on the ledger. The caller is recorded as the
+ * issuer, see requireIssuer.
  */
 func Setup(stub Wrapper, args [][]byte) ([]byte, error) {
     fmt.Println("[Ocert Scheme] [Setup]")
-    if len(args) != 0 {
-        return nil, fmt.Errorf("Incorrect arguments. Expecting no arguments")
+    if len(args) > 5 {
+        return nil, fmt.Errorf("Incorrect arguments. Expecting an optional ocert signature algorithm, threshold auditor public key, de-anonymization policy, auditor delivery key and structure preserving scheme")
+    }
+    algorithm := ""
+    if len(args) >= 1 {
+        algorithm = string(args[0])
+    }
+    schemeName := ""
+    if len(args) == 5 {
+        schemeName = string(args[4])
+    }
+    scheme, err := GetSPSScheme(schemeName)
+    if err != nil {
+        return nil, err
     }
 
-    var err error;
-    verifyProofLog, err = os.Create("/data/verifyProofLog640.txt")
+    err = putIssuerIdentity(stub)
     if err != nil {
-        fmt.Println(err)
-        panic(err.Error())
+        return nil, err
     }
 
-    auditorKeypair = []byte("NoAuditorKeyPair")
-    serialNumber = big.NewInt(0)
     sharedParams = GenerateSharedParams()
     fmt.Printf("[Ocert Scheme] [Setup] sharedParams: ")
     fmt.Println(sharedParams)
 
-    // Generate auditor's keypair
-    PKa, SKa := EKeyGen(sharedParams)
+    // Generate auditor's keypair, unless the auditors generated a
+    // threshold key among themselves
+    KPa := new(AuditorKeypair)
+    var PKa *AuditorPublicKey
+    if len(args) >= 2 && len(args[1]) > 0 {
+        TPKa := new(ThresholdAuditorPublicKey)
+        err = TPKa.SetBytes(args[1])
+        if err != nil {
+            return nil, err
+        }
+        if TPKa.Threshold < 1 || TPKa.Threshold > TPKa.N || len(TPKa.Commitments) != TPKa.Threshold ||
+                !bytes.Equal(TPKa.PK, TPKa.Commitments[0]) {
+            return nil, fmt.Errorf("Invalid threshold auditor public key")
+        }
+        err = stub.PutState("auditor_threshold_pk", args[1])
+        if err != nil {
+            return nil, err
+        }
+        PKa = TPKa.AuditorPublicKey()
+        KPa.PK = PKa.PK
+    } else {
+        var SKa *AuditorSecretKey
+        PKa, SKa = EKeyGen(sharedParams, nil)
+        KPa.PK = PKa.PK
+        KPa.SK = SKa.SK
+    }
     fmt.Printf("[Ocert Scheme] [Setup] auditor_pk: ")
     fmt.Println(PKa)
-    PKaBytes, err := PKa.Bytes()
+    err = putAuditorPublicKey(stub, PKa, 0, 0)
     if err != nil {
         return nil, err
     }
-    err = stub.PutState("auditor_pk", PKaBytes)
+
+    // Approvers of de-anonymization requests
+    if len(args) >= 3 && len(args[2]) > 0 {
+        policy := new(DeanonPolicy)
+        err = policy.SetBytes(args[2])
+        if err != nil {
+            return nil, err
+        }
+        err = putDeanonPolicy(stub, policy)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    // The auditor keypair is sealed to the delivery key
+    var recipient *ecdsa.PublicKey
+    if len(args) >= 4 && len(args[3]) > 0 {
+        if KPa.SK == nil {
+            return nil, fmt.Errorf("A threshold auditor key has no secret key to deliver")
+        }
+        recipient, err = ParseDeliveryKey(args[3])
+        if err != nil {
+            return nil, err
+        }
+        err = stub.PutState("auditor_delivery_key", args[3])
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    // Generate ocert keypair from the issuer seed
+    seed, err := transientIssuerSeed(stub)
     if err != nil {
         return nil, err
     }
-    KPa := new(AuditorKeypair)
-    KPa.PK = PKa.PK
-    KPa.SK = SKa.SK
-
-    // Generate RSA keypair
-    rsaPrivateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+    err = putIssuerSeed(stub, seed)
     if err != nil {
         return nil, err
     }
-    fmt.Printf("[Ocert Scheme] [Setup] rsa pk: ")
-    fmt.Println(&rsaPrivateKey.PublicKey)
-
-    rsaPublicKeyBytes, err := x509.MarshalPKIXPublicKey(&rsaPrivateKey.PublicKey)
+    ocertSigner, err := newOCertSigner(stub, seed, algorithm)
     if err != nil {
         return nil, err
     }
-    rsaPK := new(RSAPK)
-    rsaPK.PK = rsaPublicKeyBytes
-    rsaPKBytes, err := rsaPK.Bytes()
+    ocertPK, err := ocertSigner.PublicKey()
     if err != nil {
         return nil, err
     }
-    err = stub.PutState("rsa_pk", rsaPKBytes)
+    fmt.Printf("[Ocert Scheme] [Setup] ocert pk: ")
+    fmt.Println(ocertPK)
+
+    ocertPKBytes, err := ocertPK.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    err = stub.PutState("ocert_pk", ocertPKBytes)
     if err != nil {
         return nil, err
     }
 
-    // Generate structure preserving keypair
-    VKei, SKei := SKeyGen(sharedParams)
-    fmt.Printf("[Ocert Scheme] [Setup] sVK: ")
-    fmt.Println(VKei)
-    sSigningKey = SKei
-    SVKb, err := VKei.Bytes()
+    // Clients from before the ocert signers were pluggable read the RSA
+    // PKCS#1 v1.5 key from rsa_pk, which has the same encoding
+    if ocertPK.Algorithm == OCertSignerRSAPKCS1 {
+        err = stub.PutState("rsa_pk", ocertPKBytes)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    // Self-signed issuer certificate that X.509 ocerts chain to, there is
+    // none if the algorithm cannot be used in X.509
+    if ocertSigner.CryptoSigner() != nil {
+        ts, err := stub.GetTxTimestamp()
+        if err != nil {
+            return nil, err
+        }
+        rand := NewPRFReader(seed, []byte("issuer-certificate"))
+        issuerCertBytes, err := NewIssuerCertificate(ocertSigner, time.Unix(ts.Seconds, int64(ts.Nanos)), rand)
+        if err != nil {
+            return nil, err
+        }
+        err = stub.PutState("issuer_cert", issuerCertBytes)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    // Generate structure preserving keypair of the chosen scheme from the
+    // issuer seed
+    err = putSPSScheme(stub, scheme)
     if err != nil {
         return nil, err
     }
-    err = stub.PutState("structure_preserving_vk", SVKb)
+    VKei, err := newIssuerKey(stub, seed, scheme, 0)
+    if err != nil {
+        return nil, err
+    }
+    fmt.Printf("[Ocert Scheme] [Setup] sVK: ")
+    fmt.Println(VKei)
+    _, err = putSVK(stub, VKei, 0)
     if err != nil {
         return nil, err
     }
 
-    // Setup constants for proof
-    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
-    G := pairing.NewG1().SetBytes(sharedParams.G1)
-    H := pairing.NewG2().SetBytes(sharedParams.G2)
-    consts = new(ProofConstants)
-    consts.VK = VKei
-    consts.PPrime = nil
-    consts.PKa = PKa
-    consts.Egh = pairing.NewGT().Pair(G, H).Bytes()
-    consts.Egz = pairing.NewGT().Pair(G, pairing.NewG2().SetBytes(VKei.Z)).Bytes()
-
-    // Return keypair to the auditor
-    KPab, err := KPa.Bytes()
+    // Ecerts are valid from the first epoch
+    err = putEpoch(stub, NewEpoch(sharedParams, 0))
     if err != nil {
         return nil, err
     }
-    auditorKeypair = KPab
-    return KPab, nil
+
+    // Keep the keypair in the auditor collection and deliver it to the
+    // auditor
+    if KPa.SK != nil {
+        err = putAuditorKeypair(stub, KPa)
+        if err != nil {
+            return nil, err
+        }
+    }
+    return auditorKeyReply(recipient, KPa)
 }
 
 /*
  * GenECert is used to generate an ecert of a client
- * It takes the client id and the client's public key, and returns
- * psudonym P and ecert to the client.
+ * It takes the client's public key, derives the client id from the
+ * enrollment certificate of the caller, and returns
+ * psudonym P and ecert to the client. The ecert is only valid during
+ * the current epoch, which is returned as well, and P comes with a proof
+ * that it encrypts the client id under the auditor's public key.
+ * The request is read from the transient map, see transient.go, and the
+ * randomness is derived from the transaction ID, see randomness.go.
  */
 func GenECert(stub Wrapper, args [][]byte) ([]byte, error) {
-    if len(args) != 1 {
-        return nil, fmt.Errorf("Incorrect arguments.")
+    requestBytes, err := transientRequest(stub, args)
+    if err != nil {
+        return nil, err
     }
-
     request := new(GenECertRequest)
-    err := request.SetBytes(args[0])
+    err = request.SetBytes(requestBytes)
     if err != nil {
         return nil, err
     }
 
-    IDc := new(ClientID)
-    IDc.ID = request.IDc
+    // The client id is the enrollment of the caller, never chosen by it
+    if request.IDc != nil {
+        return nil, fmt.Errorf("IDc is derived from the creator and must not be set")
+    }
+    enrollmentID, err := CreatorEnrollmentID(stub)
+    if err != nil {
+        return nil, err
+    }
+    IDc := NewClientID(sharedParams, enrollmentID)
+    err = registerClientID(stub, enrollmentID, IDc)
+    if err != nil {
+        return nil, err
+    }
     PKc := new(ClientPublicKey)
     PKc.PK = request.PKc
 
@@ -243,12 +499,41 @@ func GenECert(stub Wrapper, args [][]byte) ([]byte, error) {
         return nil, err
     }
 
-    P := EEnc(sharedParams, PKa, IDc)
+    // Deterministic per transaction, so endorsements agree
+    requestBytes, err = request.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    pseudonymRand, err := txRandomness(stub, "genECert", requestBytes, "pseudonym")
+    if err != nil {
+        return nil, err
+    }
+    P, encProof := EEncWithProof(sharedParams, PKa, IDc, pseudonymRand)
     fmt.Printf("[Ocert Scheme] [GenECert] P: ")
     fmt.Println(P)
 
-    // Generate ecert
-    ecert := SSign(sharedParams, sSigningKey, P, PKc)
+    // Generate ecert for the current epoch
+    epoch, err := getEpoch(stub)
+    if err != nil {
+        return nil, err
+    }
+    vkVersion, err := getSVKVersion(stub)
+    if err != nil {
+        return nil, err
+    }
+    SKei, err := issuerSigningKey(stub)
+    if err != nil {
+        return nil, err
+    }
+    ecertRand, err := txRandomness(stub, "genECert", requestBytes, "ecert")
+    if err != nil {
+        return nil, err
+    }
+    scheme, err := GetSPSScheme(SKei.Scheme)
+    if err != nil {
+        return nil, err
+    }
+    ecert := scheme.Sign(sharedParams, SKei, P, PKc, epoch, ecertRand)
     fmt.Printf("[Ocert Scheme] [GenECert] ecert: ")
     fmt.Println(ecert)
 
@@ -261,25 +546,47 @@ func GenECert(stub Wrapper, args [][]byte) ([]byte, error) {
     if err != nil {
         return nil, err
     }
+    reply.Epoch, err = epoch.Bytes()
+    if err != nil {
+        return nil, err
+    }
+    reply.EncProof = encProof
+    reply.EnrollmentID = enrollmentID
+    reply.VKVersion = vkVersion
     replyBytes, err := reply.Bytes()
     if err != nil {
         return nil, err
     }
+
+    err = emitEvent(stub, &OCertEvent{Type: EventECertIssued, VKVersion: vkVersion, Epoch: epoch.Epoch})
+    if err != nil {
+        return nil, err
+    }
     return replyBytes, nil
 }
 
 /*
  * GenOCert is used to generate an ocert of a client
  * It takes a client's public key, a client's pseudonym and the 
- * proof of knowledge, and returns the ocert to the client 
+ * proof of knowledge, and returns the ocert to the client. The ocert is
+ * an X.509 certificate or a signed OCertBody, valid from the transaction
+ * timestamp, or a raw signature on PKc|P for legacy requests. Versioned
+ * ocerts are recorded in the ocert registry, see trace.go. The request
+ * is read from the transient map, see transient.go. The transaction has
+ * to be submitted by an identity that is not linked to the client, see
+ * requireUnlinkedCreator.
  */
 func GenOCert(stub Wrapper, args [][]byte) ([]byte, error) {
-    if len(args) != 1 {
-        return nil, fmt.Errorf("Incorrect arguments.")
+    err := requireUnlinkedCreator(stub)
+    if err != nil {
+        return nil, err
+    }
+    requestBytes, err := transientRequest(stub, args)
+    if err != nil {
+        return nil, err
     }
-
     request := new(GenOCertRequest)
-    err := request.SetBytes(args[0])
+    err = request.SetBytes(requestBytes)
     if err != nil {
         return nil, err
     }
@@ -305,12 +612,24 @@ func GenOCert(stub Wrapper, args [][]byte) ([]byte, error) {
     fmt.Printf("[Ocert Scheme] [GenOCert] pi: ")
     pi.Print()
 
+    // The ecert has to be signed for the current epoch
+    epoch, err := getEpoch(stub)
+    if err != nil {
+        return nil, err
+    }
+
+    // The ecert may be signed with an older key in its grace period
+    c, err := proofConstants(stub, request.VKVersion)
+    if err != nil {
+        return nil, err
+    }
+
     // Verify proof of knowledge
     start := time.Now()
 
-    consts.PPrime = P
-    result := PProve(sharedParams, pi, consts)
-    consts.PPrime = nil
+    c.PPrime = P
+    c.Epoch = epoch.E
+    result := PProve(sharedParams, pi, c)
 
     end := time.Now()
     elapsed := end.Sub(start)
@@ -321,26 +640,100 @@ func GenOCert(stub Wrapper, args [][]byte) ([]byte, error) {
     if !result {
         return nil, fmt.Errorf("Proof verfication fails")
     }
-    verifyProofLog.WriteString("verifyProof: " + elapsed.String() + "\n")
+    io.WriteString(verifyProofLog, "verifyProof: " + elapsed.String() + "\n")
 
-    // TODO generate X.509 certificate
-    msg, err := OCertSingedBytes(PKc, P)
+    // The issuer key and the randomness of the signature, RSA-PSS is the
+    // only signature that is not deterministic
+    ocertSigner, err := issuerOCertSigner(stub)
+    if err != nil {
+        return nil, err
+    }
+    signatureRand, err := txRandomness(stub, "genOCert", requestBytes, "signature")
     if err != nil {
         return nil, err
     }
-    hashed := sha256.Sum256(msg)
-    signature, err := rsa.SignPKCS1v15(rand.Reader, rsaPrivateKey, crypto.SHA256, hashed[:])
+
+    reply := new(GenOCertReply)
+    if request.Version == OCertVersionX509 {
+        issuerCertificate, err := getIssuerCertificate(stub)
+        if err != nil {
+            return nil, err
+        }
+        if issuerCertificate == nil {
+            return nil, fmt.Errorf("X.509 ocerts are not supported by %s", ocertSigner.Algorithm())
+        }
+        ts, err := stub.GetTxTimestamp()
+        if err != nil {
+            return nil, err
+        }
+        now := time.Unix(ts.Seconds, int64(ts.Nanos))
+        serial := OCertSerial(stub.GetTxID())
+        reply.Cert, err = NewX509OCert(issuerCertificate, ocertSigner, PKc, P, serial, now, signatureRand)
+        if err != nil {
+            return nil, err
+        }
+        err = registerOCert(stub, serial.Bytes(), P, now.Unix())
+        if err != nil {
+            return nil, err
+        }
+        err = emitEvent(stub, &OCertEvent{Type: EventOCertIssued, Serial: serial.Bytes(), VKVersion: request.VKVersion, Epoch: epoch.Epoch})
+        if err != nil {
+            return nil, err
+        }
+        return reply.Bytes()
+    }
+
+    var msg, serial []byte
+    if request.Version == 0 {
+        // Legacy ocert, the signature on PKc|P only
+        msg, err = OCertSingedBytes(PKc, P)
+        if err != nil {
+            return nil, err
+        }
+    } else if request.Version == OCertVersion {
+        ts, err := stub.GetTxTimestamp()
+        if err != nil {
+            return nil, err
+        }
+        now := time.Unix(ts.Seconds, int64(ts.Nanos))
+        ocertPK, err := ocertSigner.PublicKey()
+        if err != nil {
+            return nil, err
+        }
+        body, err := NewOCertBody(PKc, P, OCertSerial(stub.GetTxID()), OCertIssuerID(ocertPK.PK), now)
+        if err != nil {
+            return nil, err
+        }
+        err = registerOCert(stub, body.Serial, P, body.NotBefore)
+        if err != nil {
+            return nil, err
+        }
+        serial = body.Serial
+        msg, err = body.Bytes()
+        if err != nil {
+            return nil, err
+        }
+        reply.Body = msg
+    } else {
+        return nil, fmt.Errorf("Unsupported ocert version: %d", request.Version)
+    }
+    signature, err := ocertSigner.Sign(msg, signatureRand)
     if err != nil {
         return nil, err
     }
     fmt.Printf("[Ocert Scheme] [GenOCert] signature: ")
     fmt.Println(signature)
 
-    reply := new(GenOCertReply)
     reply.Sig = signature
     replyBytes, err := reply.Bytes()
     if err != nil {
         return nil, err
     }
+
+    // Legacy ocerts have no serial
+    err = emitEvent(stub, &OCertEvent{Type: EventOCertIssued, Serial: serial, VKVersion: request.VKVersion, Epoch: epoch.Epoch})
+    if err != nil {
+        return nil, err
+    }
     return replyBytes, nil
 }
\ No newline at end of file
@@ -0,0 +1,101 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ocert
+
+import (
+    "bytes"
+    "crypto/x509"
+    "encoding/json"
+    "testing"
+)
+
+/*
+ * Only the identity that called Setup may advance the epoch
+ */
+func TestAdvanceEpoch(t *testing.T) {
+    stub := NewMemoryStub()
+    issuer := []byte("issuer")
+    stub.Creator = issuer
+    if _, err := Setup(stub, [][]byte{[]byte("")}); err != nil {
+        t.Fatal(err)
+    }
+
+    stub.Creator = []byte("client")
+    if _, err := invokeTx(t, stub, 0, AdvanceEpoch, nil); err == nil {
+        t.Error("a client advances the epoch")
+    }
+    stub.Creator = nil
+    if _, err := invokeTx(t, stub, 0, AdvanceEpoch, nil); err == nil {
+        t.Error("advances the epoch without a caller identity")
+    }
+
+    stub.Creator = issuer
+    epochBytes, err := invokeTx(t, stub, 0, AdvanceEpoch, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    epoch := new(Epoch)
+    if err := epoch.SetBytes(epochBytes); err != nil || epoch.Epoch != 1 {
+        t.Errorf("advanced to epoch %d: %v", epoch.Epoch, err)
+    }
+
+    // Without a caller identity at Setup nobody is the issuer
+    stub = NewMemoryStub()
+    if _, err := Setup(stub, [][]byte{[]byte("")}); err != nil {
+        t.Fatal(err)
+    }
+    stub.Creator = issuer
+    if _, err := invokeTx(t, stub, 0, AdvanceEpoch, nil); err == nil {
+        t.Error("advances the epoch without an issuer")
+    }
+}
+
+/*
+ * The RSA PKCS#1 v1.5 ocert key is also kept under rsa_pk, readable as
+ * before the signers were pluggable, and other keys are not
+ */
+func TestSetupRSAPK(t *testing.T) {
+    stub := NewMemoryStub()
+    if _, err := Setup(stub, [][]byte{[]byte("")}); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    value := stub.State["rsa_pk"]
+    if value == nil || !bytes.Equal(value, stub.State["ocert_pk"]) {
+        t.Fatal("rsa_pk differs from ocert_pk")
+    }
+    legacy := new(struct {
+        PK []byte
+    })
+    if err := json.Unmarshal(value, legacy); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := x509.ParsePKIXPublicKey(legacy.PK); err != nil {
+        t.Errorf("rsa_pk is not a PKIX key: %v", err)
+    }
+
+    stub = NewMemoryStub()
+    if _, err := Setup(stub, [][]byte{[]byte(OCertSignerEd25519)}); err != nil {
+        t.Fatal(err)
+    }
+    stub.NextTx()
+    if stub.State["rsa_pk"] != nil {
+        t.Error("rsa_pk for an Ed25519 key")
+    }
+}
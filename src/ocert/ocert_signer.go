@@ -0,0 +1,479 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Signature schemes used by the issuer to sign ocerts. The algorithm is
+ * selected in Setup and stored with the public key on the ledger (OCertPK),
+ * so a verifier picks the algorithm from the public key entry. The keys
+ * are drawn from the issuer seed and the signatures are deterministic, or
+ * use the randomness of the transaction, so every endorser of the issuer
+ * produces the same ocert.
+ */
+
+package ocert
+
+import (
+    "fmt"
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/elliptic"
+    cryptorand "crypto/rand"
+    "crypto/rsa"
+    "crypto/sha256"
+    "crypto/x509"
+    "io"
+    "math/big"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * Names of the ocert signature algorithms, stored in OCertPK.Algorithm
+ */
+const (
+    OCertSignerRSAPKCS1 = "rsa-pkcs1v15"
+    OCertSignerRSAPSS   = "rsa-pss"
+    OCertSignerECDSA    = "ecdsa-p256"
+    OCertSignerEd25519  = "ed25519"
+    OCertSignerBLS      = "bls"
+)
+
+/*
+ * OCertSigner holds the issuer private key and signs ocerts. Sign hashes
+ * msg itself, where it is needed by the algorithm, and only RSA-PSS reads
+ * rand, for its salt. CryptoSigner returns the key as a crypto.Signer to
+ * issue X.509 certificates, or nil if the algorithm cannot be used in
+ * X.509. PrivateKey encodes the key for the issuer collection, see
+ * ParseOCertSigner.
+ */
+type OCertSigner interface {
+    Algorithm() string
+    PublicKey() (*OCertPK, error)
+    PrivateKey() ([]byte, error)
+    Sign(msg []byte, rand io.Reader) ([]byte, error)
+    CryptoSigner() crypto.Signer
+}
+
+/*
+ * Generate a new issuer key for the algorithm from rand, or from
+ * crypto/rand if rand is nil. The empty name is the RSA PKCS#1 v1.5
+ * signature used before the algorithms were named. The key generation of
+ * crypto/rsa, crypto/ecdsa and crypto/ed25519 ignores its reader, so the
+ * keys are derived here and the same stream always gives the same key.
+ */
+func NewOCertSigner(sharedParams *SharedParams, algorithm string, rand io.Reader) (OCertSigner, error) {
+    if algorithm == OCertSignerBLS {
+        return newBLSSigner(sharedParams, rand), nil
+    }
+    if rand == nil {
+        rand = cryptorand.Reader
+    }
+    switch algorithm {
+    case "", OCertSignerRSAPKCS1, OCertSignerRSAPSS:
+        key, err := rsaKeyFromReader(rand, 2048)
+        if err != nil {
+            return nil, err
+        }
+        if algorithm == OCertSignerRSAPSS {
+            return &rsaPSSSigner{key}, nil
+        }
+        return &rsaPKCS1Signer{key}, nil
+    case OCertSignerECDSA:
+        // d in [1, n-1], 64 bytes are reduced so the bias is negligible
+        buf := make([]byte, 64)
+        _, err := io.ReadFull(rand, buf)
+        if err != nil {
+            return nil, err
+        }
+        n := new(big.Int).Sub(elliptic.P256().Params().N, big.NewInt(1))
+        d := new(big.Int).Mod(new(big.Int).SetBytes(buf), n)
+        d.Add(d, big.NewInt(1))
+        key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), d.FillBytes(make([]byte, 32)))
+        if err != nil {
+            return nil, err
+        }
+        return &ecdsaSigner{key}, nil
+    case OCertSignerEd25519:
+        seed := make([]byte, ed25519.SeedSize)
+        _, err := io.ReadFull(rand, seed)
+        if err != nil {
+            return nil, err
+        }
+        return &ed25519Signer{ed25519.NewKeyFromSeed(seed)}, nil
+    }
+    return nil, fmt.Errorf("Unknown ocert signature algorithm: %s", algorithm)
+}
+
+/*
+ * Parse the private key of an ocert signer encoded by PrivateKey: PKCS#8
+ * for the X.509 algorithms, the scalar x for BLS
+ */
+func ParseOCertSigner(sharedParams *SharedParams, algorithm string, value []byte) (OCertSigner, error) {
+    if algorithm == OCertSignerBLS {
+        signer := new(blsSigner)
+        signer.sharedParams = sharedParams
+        signer.x = value
+        return signer, nil
+    }
+
+    key, err := x509.ParsePKCS8PrivateKey(value)
+    if err != nil {
+        return nil, err
+    }
+    switch algorithm {
+    case "", OCertSignerRSAPKCS1, OCertSignerRSAPSS:
+        rsaKey, ok := key.(*rsa.PrivateKey)
+        if !ok {
+            return nil, fmt.Errorf("Ocert private key is not an RSA key")
+        }
+        if algorithm == OCertSignerRSAPSS {
+            return &rsaPSSSigner{rsaKey}, nil
+        }
+        return &rsaPKCS1Signer{rsaKey}, nil
+    case OCertSignerECDSA:
+        ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+        if !ok {
+            return nil, fmt.Errorf("Ocert private key is not an ECDSA key")
+        }
+        return &ecdsaSigner{ecdsaKey}, nil
+    case OCertSignerEd25519:
+        ed25519Key, ok := key.(ed25519.PrivateKey)
+        if !ok {
+            return nil, fmt.Errorf("Ocert private key is not an Ed25519 key")
+        }
+        return &ed25519Signer{ed25519Key}, nil
+    }
+    return nil, fmt.Errorf("Unknown ocert signature algorithm: %s", algorithm)
+}
+
+/*
+ * An RSA key with public exponent 65537 whose primes are searched from
+ * rand. The two top bits of each prime are set, so N has exactly bits bits.
+ */
+func rsaKeyFromReader(rand io.Reader, bits int) (*rsa.PrivateKey, error) {
+    e := big.NewInt(65537)
+    one := big.NewInt(1)
+    for {
+        p, err := primeFromReader(rand, bits / 2)
+        if err != nil {
+            return nil, err
+        }
+        q, err := primeFromReader(rand, bits - bits / 2)
+        if err != nil {
+            return nil, err
+        }
+        if p.Cmp(q) == 0 {
+            continue
+        }
+        phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+        d := new(big.Int).ModInverse(e, phi)
+        if d == nil {
+            continue
+        }
+        key := new(rsa.PrivateKey)
+        key.N = new(big.Int).Mul(p, q)
+        key.E = int(e.Int64())
+        key.D = d
+        key.Primes = []*big.Int{p, q}
+        key.Precompute()
+        err = key.Validate()
+        if err != nil {
+            return nil, err
+        }
+        return key, nil
+    }
+}
+
+func primeFromReader(rand io.Reader, bits int) (*big.Int, error) {
+    buf := make([]byte, (bits + 7) / 8)
+    for {
+        _, err := io.ReadFull(rand, buf)
+        if err != nil {
+            return nil, err
+        }
+        p := new(big.Int).SetBytes(buf)
+        for i := bits; i < 8 * len(buf); i++ {
+            p.SetBit(p, i, 0)
+        }
+        p.SetBit(p, bits - 1, 1)
+        p.SetBit(p, bits - 2, 1)
+        p.SetBit(p, 0, 1)
+        if p.ProbablyPrime(20) {
+            return p, nil
+        }
+    }
+}
+
+/*
+ * Verify a signature on msg with the issuer public key, using the algorithm
+ * recorded in the public key. sharedParams is only used by BLS.
+ */
+func OCertVerifySignature(sharedParams *SharedParams, pk *OCertPK, msg []byte, sig []byte) error {
+    if pk.Algorithm == OCertSignerBLS {
+        if !blsVerify(sharedParams, pk.PK, msg, sig) {
+            return fmt.Errorf("Invalid BLS signature")
+        }
+        return nil
+    }
+
+    publicKey, err := x509.ParsePKIXPublicKey(pk.PK)
+    if err != nil {
+        return err
+    }
+    hashed := sha256.Sum256(msg)
+
+    switch pk.Algorithm {
+    case "", OCertSignerRSAPKCS1, OCertSignerRSAPSS:
+        rsaPK, ok := publicKey.(*rsa.PublicKey)
+        if !ok {
+            return fmt.Errorf("Ocert public key is not an RSA key")
+        }
+        if pk.Algorithm == OCertSignerRSAPSS {
+            opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+            return rsa.VerifyPSS(rsaPK, crypto.SHA256, hashed[:], sig, opts)
+        }
+        return rsa.VerifyPKCS1v15(rsaPK, crypto.SHA256, hashed[:], sig)
+    case OCertSignerECDSA:
+        ecdsaPK, ok := publicKey.(*ecdsa.PublicKey)
+        if !ok {
+            return fmt.Errorf("Ocert public key is not an ECDSA key")
+        }
+        if !ecdsa.VerifyASN1(ecdsaPK, hashed[:], sig) {
+            return fmt.Errorf("Invalid ECDSA signature")
+        }
+        return nil
+    case OCertSignerEd25519:
+        ed25519PK, ok := publicKey.(ed25519.PublicKey)
+        if !ok {
+            return fmt.Errorf("Ocert public key is not an Ed25519 key")
+        }
+        if !ed25519.Verify(ed25519PK, msg, sig) {
+            return fmt.Errorf("Invalid Ed25519 signature")
+        }
+        return nil
+    }
+    return fmt.Errorf("Unknown ocert signature algorithm: %s", pk.Algorithm)
+}
+
+func pkixOCertPK(algorithm string, publicKey interface{}) (*OCertPK, error) {
+    pkBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+    if err != nil {
+        return nil, err
+    }
+    pk := new(OCertPK)
+    pk.Algorithm = algorithm
+    pk.PK = pkBytes
+    return pk, nil
+}
+
+/*****************************************************************/
+
+/*
+ * RSA-2048 with PKCS#1 v1.5 padding over SHA-256
+ */
+type rsaPKCS1Signer struct {
+    key *rsa.PrivateKey
+}
+
+func (signer *rsaPKCS1Signer) Algorithm() string {
+    return OCertSignerRSAPKCS1
+}
+
+func (signer *rsaPKCS1Signer) PublicKey() (*OCertPK, error) {
+    return pkixOCertPK(OCertSignerRSAPKCS1, &signer.key.PublicKey)
+}
+
+func (signer *rsaPKCS1Signer) PrivateKey() ([]byte, error) {
+    return x509.MarshalPKCS8PrivateKey(signer.key)
+}
+
+func (signer *rsaPKCS1Signer) Sign(msg []byte, rand io.Reader) ([]byte, error) {
+    hashed := sha256.Sum256(msg)
+    return rsa.SignPKCS1v15(nil, signer.key, crypto.SHA256, hashed[:])
+}
+
+func (signer *rsaPKCS1Signer) CryptoSigner() crypto.Signer {
+    return signer.key
+}
+
+/*
+ * RSA-2048 with PSS padding over SHA-256, the salt is as long as the hash
+ */
+type rsaPSSSigner struct {
+    key *rsa.PrivateKey
+}
+
+func (signer *rsaPSSSigner) Algorithm() string {
+    return OCertSignerRSAPSS
+}
+
+func (signer *rsaPSSSigner) PublicKey() (*OCertPK, error) {
+    return pkixOCertPK(OCertSignerRSAPSS, &signer.key.PublicKey)
+}
+
+func (signer *rsaPSSSigner) PrivateKey() ([]byte, error) {
+    return x509.MarshalPKCS8PrivateKey(signer.key)
+}
+
+func (signer *rsaPSSSigner) Sign(msg []byte, rand io.Reader) ([]byte, error) {
+    if rand == nil {
+        rand = cryptorand.Reader
+    }
+    hashed := sha256.Sum256(msg)
+    opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+    return rsa.SignPSS(rand, signer.key, crypto.SHA256, hashed[:], opts)
+}
+
+func (signer *rsaPSSSigner) CryptoSigner() crypto.Signer {
+    return signer.key
+}
+
+/*
+ * ECDSA on P-256 over SHA-256, the signature is ASN.1 encoded. The nonce
+ * is derived from the key and the message (RFC 6979), also when signing
+ * X.509 certificates.
+ */
+type ecdsaSigner struct {
+    key *ecdsa.PrivateKey
+}
+
+func (signer *ecdsaSigner) Algorithm() string {
+    return OCertSignerECDSA
+}
+
+func (signer *ecdsaSigner) PublicKey() (*OCertPK, error) {
+    return pkixOCertPK(OCertSignerECDSA, &signer.key.PublicKey)
+}
+
+func (signer *ecdsaSigner) PrivateKey() ([]byte, error) {
+    return x509.MarshalPKCS8PrivateKey(signer.key)
+}
+
+func (signer *ecdsaSigner) Sign(msg []byte, rand io.Reader) ([]byte, error) {
+    hashed := sha256.Sum256(msg)
+    return signer.key.Sign(nil, hashed[:], crypto.SHA256)
+}
+
+func (signer *ecdsaSigner) CryptoSigner() crypto.Signer {
+    return &rfc6979Signer{signer.key}
+}
+
+/*
+ * An ECDSA key as a crypto.Signer that ignores the reader of the caller,
+ * crypto/ecdsa signs deterministically only without one
+ */
+type rfc6979Signer struct {
+    key *ecdsa.PrivateKey
+}
+
+func (signer *rfc6979Signer) Public() crypto.PublicKey {
+    return signer.key.Public()
+}
+
+func (signer *rfc6979Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+    return signer.key.Sign(nil, digest, opts)
+}
+
+/*
+ * Ed25519, which signs the message itself
+ */
+type ed25519Signer struct {
+    key ed25519.PrivateKey
+}
+
+func (signer *ed25519Signer) Algorithm() string {
+    return OCertSignerEd25519
+}
+
+func (signer *ed25519Signer) PublicKey() (*OCertPK, error) {
+    return pkixOCertPK(OCertSignerEd25519, signer.key.Public())
+}
+
+func (signer *ed25519Signer) PrivateKey() ([]byte, error) {
+    return x509.MarshalPKCS8PrivateKey(signer.key)
+}
+
+func (signer *ed25519Signer) Sign(msg []byte, rand io.Reader) ([]byte, error) {
+    return ed25519.Sign(signer.key, msg), nil
+}
+
+func (signer *ed25519Signer) CryptoSigner() crypto.Signer {
+    return signer.key
+}
+
+/*
+ * BLS signature on the shared bilinear group.
+ * SK = x, picked from rand in group of units modulo p
+ * PK = x * g2 in G2
+ * sig = x * H(msg) in G1, where H hashes SHA-256(msg) into G1
+ * and to verify, test e(sig, g2) = e(H(msg), PK)
+ */
+type blsSigner struct {
+    sharedParams *SharedParams
+    x            []byte
+}
+
+func newBLSSigner(sharedParams *SharedParams, rand io.Reader) *blsSigner {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    signer := new(blsSigner)
+    signer.sharedParams = sharedParams
+    signer.x = randZr(pairing, rand).Bytes()
+    return signer
+}
+
+func (signer *blsSigner) Algorithm() string {
+    return OCertSignerBLS
+}
+
+func (signer *blsSigner) PublicKey() (*OCertPK, error) {
+    pairing, _ := pbc.NewPairingFromString(signer.sharedParams.Params)
+    g2 := pairing.NewG2().SetBytes(signer.sharedParams.G2)
+    x := pairing.NewZr().SetBytes(signer.x)
+
+    pk := new(OCertPK)
+    pk.Algorithm = OCertSignerBLS
+    pk.PK = pairing.NewG2().MulZn(g2, x).Bytes()
+    return pk, nil
+}
+
+func (signer *blsSigner) PrivateKey() ([]byte, error) {
+    return signer.x, nil
+}
+
+func (signer *blsSigner) Sign(msg []byte, rand io.Reader) ([]byte, error) {
+    pairing, _ := pbc.NewPairingFromString(signer.sharedParams.Params)
+    x := pairing.NewZr().SetBytes(signer.x)
+    hashed := sha256.Sum256(msg)
+    h := pairing.NewG1().SetFromHash(hashed[:])
+    return pairing.NewG1().MulZn(h, x).Bytes(), nil
+}
+
+func (signer *blsSigner) CryptoSigner() crypto.Signer {
+    return nil
+}
+
+func blsVerify(sharedParams *SharedParams, PK []byte, msg []byte, sig []byte) bool {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g2 := pairing.NewG2().SetBytes(sharedParams.G2)
+    hashed := sha256.Sum256(msg)
+    h := pairing.NewG1().SetFromHash(hashed[:])
+
+    e1 := pairing.NewGT().Pair(pairing.NewG1().SetBytes(sig), g2)
+    e2 := pairing.NewGT().Pair(h, pairing.NewG2().SetBytes(PK))
+    return e1.Equals(e2)
+}
@@ -24,6 +24,7 @@ package ocert
 
 import (
     "github.com/Nik-U/pbc"
+    "io"
     "reflect"
 )
 
@@ -32,33 +33,36 @@ import (
  * of equations(e.g. pairing product equations and multi-scalar multiplication
  * equations) and outputs proof (e.g pi and theta ...)
  */
-func PSetup(sharedParams *SharedParams, vars *ProofVariables) *ProofOfKnowledge {
+func PSetup(sharedParams *SharedParams, vars *ProofVariables, rand io.Reader) *ProofOfKnowledge {
     pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
-    g1 := pairing.NewG1().Rand()
-    g2 := pairing.NewG2().Rand()
-    gt := pairing.NewGT().Pair(g1, g2)
-    _ = gt
 
     pi := new(ProofOfKnowledge)
-    alpha := pairing.NewZr().Rand() // Seed for CRS
-    sigma := CreateCommonReferenceString(sharedParams, alpha) // CRS
+    alpha := randZr(pairing, rand) // Seed for CRS
+    sigma := CreateCommonReferenceString(sharedParams, alpha, rand) // CRS
 
     // Setup proof of eq1
     Xc := pairing.NewZr().SetBytes(vars.Xc)
     H := pairing.NewG2().SetBytes(sharedParams.G2)
     negPKc := pairing.NewG2().Neg(pairing.NewG2().SetBytes(vars.PKc.PK))
-    pi.Eq1 = ProveEquation1(pairing, Xc, H, negPKc, sigma)
+    pi.Eq1 = ProveEquation1(pairing, Xc, H, negPKc, sigma, rand)
 
     // Setup proof of eq2
     C := pairing.NewG1().SetBytes(vars.P.C)
     G := pairing.NewG1().SetBytes(sharedParams.G1)
     rprime := pairing.NewZr().SetBytes(vars.RPrime)
-    pi.Eq2 = ProveEquation2(pairing, rprime, G, C, sigma)
+    pi.Eq2 = ProveEquation2(pairing, rprime, G, C, sigma, rand)
 
     // Setup proof of eq3
     D := pairing.NewG1().SetBytes(vars.P.D)
     PKa := pairing.NewG1().SetBytes(vars.PKa.PK)
-    pi.Eq3 = ProveEquation2(pairing, rprime, PKa, D, sigma)
+    pi.Eq3 = ProveEquation2(pairing, rprime, PKa, D, sigma, rand)
+
+    // The ecert equations depend on the structure preserving scheme
+    if vars.VK.Scheme == SPSSchemeDualAGHO {
+        pi.Eq4, pi.Eq5 = proveDualECert(pairing, sharedParams, vars, sigma, rand)
+        pi.sigma = sigma
+        return pi
+    }
 
     // Setup proof of eq4
     // Vars
@@ -73,7 +77,7 @@ func PSetup(sharedParams *SharedParams, vars *ProofVariables) *ProofOfKnowledge
     W2 := pairing.NewG2().SetBytes(vars.VK.W2)
     _ = H
 
-    pi.Eq4 = ProveEquation4(pairing, R, S, C, D, V, H, W1, W2, sigma)
+    pi.Eq4 = ProveEquation4(pairing, R, S, C, D, V, H, W1, W2, sigma, rand)
 
     // Setup proof of eq5
     _ = R
@@ -81,7 +85,7 @@ func PSetup(sharedParams *SharedParams, vars *ProofVariables) *ProofOfKnowledge
     PKc := pairing.NewG2().SetBytes(vars.PKc.PK)
     U := pairing.NewG1().SetBytes(vars.VK.U)
 
-    pi.Eq5 = ProveEquation5(pairing, R, T, PKc, U, sigma)
+    pi.Eq5 = ProveEquation5(pairing, R, T, PKc, U, sigma, rand)
 
     // Set CRSf
     pi.sigma = sigma
@@ -94,10 +98,6 @@ func PSetup(sharedParams *SharedParams, vars *ProofVariables) *ProofOfKnowledge
  */
 func PProve(sharedParams *SharedParams, pi *ProofOfKnowledge, consts *ProofConstants) bool {
     pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
-    g1 := pairing.NewG1().Rand()
-    g2 := pairing.NewG2().Rand()
-    gt := pairing.NewGT().Pair(g1, g2)
-    _ = gt
 
     // Validate eq1
     H := pairing.NewG2().SetBytes(sharedParams.G2)
@@ -122,12 +122,24 @@ func PProve(sharedParams *SharedParams, pi *ProofOfKnowledge, consts *ProofConst
 
     // fmt.Println("EQ3:", retVal3)
 
+    // The ecert equations depend on the structure preserving scheme
+    if consts.VK.Scheme == SPSSchemeDualAGHO {
+        return retVal && verifyDualECert(pairing, sharedParams, pi, consts)
+    }
+
     // Validate eq4
     V := pairing.NewG2().SetBytes(consts.VK.V)
     _ = H
     W1 := pairing.NewG2().SetBytes(consts.VK.W1)
     W2 := pairing.NewG2().SetBytes(consts.VK.W2)
     eGZ := pairing.NewGT().SetBytes(consts.Egz)
+    if consts.Epoch != nil {
+        // The ecert signs the current epoch E, move e(E, W3) to the target
+        E := pairing.NewG1().SetBytes(consts.Epoch)
+        W3 := pairing.NewG2().SetBytes(consts.VK.W3)
+        eEW := pairing.NewGT().Pair(E, W3)
+        eGZ = pairing.NewGT().Div(eGZ, eEW)
+    }
     retVal4 := VerifyEquation4(pairing, pi.Eq4, V, H, W1, W2, eGZ, pi.sigma)
     retVal = retVal && retVal4
 
@@ -157,18 +169,18 @@ func PProve(sharedParams *SharedParams, pi *ProofOfKnowledge, consts *ProofConst
  *    Pi     := r*ι_2(H) + r*lambda*ι_2(PKc)  + (r*lambda*S - T')*V
  *    Theta  := S'*ι'_1(-1) + S*lambda*ι'_1(xc) + Tu_1
  */
-func ProveEquation1(pairing *pbc.Pairing, xc *pbc.Element, H *pbc.Element, PKc *pbc.Element, sigma *CommonReferenceString) *ProofOfEquation{
+func ProveEquation1(pairing *pbc.Pairing, xc *pbc.Element, H *pbc.Element, PKc *pbc.Element, sigma *CommonReferenceString, rand io.Reader) *ProofOfEquation{
     proof := new(ProofOfEquation)
 
     // Create commitment in B1 for Xc
-    cprime, _, R := CreateCommitmentPrimeOnG1(pairing, []*pbc.Element{xc}, sigma)
+    cprime, _, R := CreateCommitmentPrimeOnG1(pairing, []*pbc.Element{xc}, sigma, rand)
     if R.cols != 1 && R.rows != 1 {
         panic("Issues in conversion and creation of samples in Zp for R")
     }
     r := R.mat[0][0]
 
     // Create commitment in B2 for PKc
-    d, _, S := CreateCommitmentOnG2(pairing, []*pbc.Element{PKc}, sigma)
+    d, _, S := CreateCommitmentOnG2(pairing, []*pbc.Element{PKc}, sigma, rand)
     if S.rows != 1 && S.cols != 2 {
         panic("Issues in conversion and creation of samples in Zp for S")
     }
@@ -182,7 +194,7 @@ func ProveEquation1(pairing *pbc.Pairing, xc *pbc.Element, H *pbc.Element, PKc *
     Hir := Hi.MulScalarInG2(pairing, r)           // r*ι_2(H)
     // +
     // Create Phi := (r*lambada*S - T)
-    T := NewRMatrix(pairing, 2, 1)
+    T := NewRMatrix(pairing, 2, 1, rand)
     Ti := T.InvertMatrix()                 // T' invert
     Vphi := Ti.MulCommitmentKeysG2(pairing, sigma.V) // (r*gamma*S - T')V (commitment key in G2)
     if len(Vphi) > 1{
@@ -238,17 +250,17 @@ func ProveEquation1(pairing *pbc.Pairing, xc *pbc.Element, H *pbc.Element, PKc *
  *    Pi     := R'*ι'_2(1) + R'*lambda*ι'_2(rprime)  + (R'*lambda*s - T')*v_1
  *    Theta  := s*ι_1(C) + s*lambda*ι_1(G) + TU
  */
-func ProveEquation2(pairing *pbc.Pairing, rprime *pbc.Element, G *pbc.Element, C *pbc.Element, sigma *CommonReferenceString) *ProofOfEquation{
+func ProveEquation2(pairing *pbc.Pairing, rprime *pbc.Element, G *pbc.Element, C *pbc.Element, sigma *CommonReferenceString, rand io.Reader) *ProofOfEquation{
     proof := new(ProofOfEquation)
 
     // Create commitment in B1 for C
-    c, _, R := CreateCommitmentOnG1(pairing, []*pbc.Element{C}, sigma)
+    c, _, R := CreateCommitmentOnG1(pairing, []*pbc.Element{C}, sigma, rand)
     if R.rows != 1 && R.cols != 2 {
         panic("Issues in conversion and creation of samples in Zp for R")
     }
 
     // Create commitment in B2 for r' TODO: This should be in B2 - Done : Check?
-    dprime, _, S := CreateCommitmentPrimeOnG2(pairing, []*pbc.Element{rprime}, sigma)
+    dprime, _, S := CreateCommitmentPrimeOnG2(pairing, []*pbc.Element{rprime}, sigma, rand)
     if S.cols != 1 && S.rows != 1 {
         panic("Issues in conversion and creation of samples in Zp for S")
     }
@@ -264,7 +276,7 @@ func ProveEquation2(pairing *pbc.Pairing, rprime *pbc.Element, G *pbc.Element, C
     pos1 := IotaPrime2(pairing, pairing.NewZr().Set1(), sigma)
     Rpos := Ri.MulBScalarinB2(pairing, *pos1)     // R'*ι'_2(1)
     // +
-    T := NewRMatrix(pairing, 1, 2)
+    T := NewRMatrix(pairing, 1, 2, rand)
     Ti := T.InvertMatrix()                 // T' invert
     Tv := Ti.MulCommitmentKeysG2(pairing, []CommitmentKey{sigma.V[0]})
 
@@ -331,12 +343,13 @@ func ProveEquation4(pairing *pbc.Pairing,
     H *pbc.Element,
     W1 *pbc.Element,
     W2 *pbc.Element,
-    sigma *CommonReferenceString) *ProofOfEquation {
+    sigma *CommonReferenceString,
+    rand io.Reader) *ProofOfEquation {
     proof := new(ProofOfEquation)
 
     //// Create commitment in B2
     Xvec := []*pbc.Element{R, S, C, D}
-    c_commit, _, Rmat := CreateCommitmentOnG1(pairing, Xvec, sigma) // T, PKc
+    c_commit, _, Rmat := CreateCommitmentOnG1(pairing, Xvec, sigma, rand) // T, PKc
     if Rmat.rows != 4 && Rmat.cols != 2 && len(c_commit) == 4 {
         panic("Issues in conversion and creation of samples in Zp for S")
     }
@@ -367,7 +380,7 @@ func ProveEquation4(pairing *pbc.Pairing,
 
     // -
 
-    Tmat := NewRMatrix(pairing, 2, 2)
+    Tmat := NewRMatrix(pairing, 2, 2, rand)
     Ti := Tmat.InvertMatrix()
     Tv := Ti.MulCommitmentKeysG2(pairing, sigma.V)
     if len(Tv) != 2 {
@@ -418,11 +431,12 @@ func ProveEquation5(pairing *pbc.Pairing,
     T *pbc.Element,
     PKc *pbc.Element,
     U *pbc.Element,
-    sigma *CommonReferenceString) *ProofOfEquation {
+    sigma *CommonReferenceString,
+    rand io.Reader) *ProofOfEquation {
     proof := new(ProofOfEquation)
 
     // Create commitment in B1 for Xc
-    c, _, Rmat := CreateCommitmentOnG1(pairing, []*pbc.Element{R}, sigma)
+    c, _, Rmat := CreateCommitmentOnG1(pairing, []*pbc.Element{R}, sigma, rand)
     if Rmat.cols != 2 && Rmat.rows != 1 {
         panic("Issues in conversion and creation of samples in Zp for R")
     }
@@ -433,7 +447,7 @@ func ProveEquation5(pairing *pbc.Pairing,
         {Iota2(pairing, PKc)},
     }
     Yvec := []*pbc.Element{T, PKc}
-    d, _, Smat := CreateCommitmentOnG2(pairing, Yvec, sigma) // T, PKc
+    d, _, Smat := CreateCommitmentOnG2(pairing, Yvec, sigma, rand) // T, PKc
     if Smat.rows != 2 && Smat.cols != 2 {
         panic("Issues in conversion and creation of samples in Zp for S")
     }
@@ -451,7 +465,7 @@ func ProveEquation5(pairing *pbc.Pairing,
     //fmt.Println("Gamma:", Gamma.mat)
 
     Ri := Rmat.InvertMatrix()
-    Tmat := NewRMatrix(pairing, 2, 2)
+    Tmat := NewRMatrix(pairing, 2, 2, rand)
     Ti := Tmat.InvertMatrix()
 
     Biota := Iota2(pairing, pairing.NewG2().Set0())
@@ -661,6 +675,9 @@ func VerifyEquation4(
     W2 *pbc.Element,
     tau *pbc.Element,
     sigma *CommonReferenceString) bool {
+    if len(proof.c) != 4 || len(proof.Pi) != 2 || len(proof.Theta) != 2 {
+        return false
+    }
 
     // Construct LHS
     Vi := Iota2(pairing, V)
@@ -707,6 +724,9 @@ func VerifyEquation4(
  * Verify Equation 5
  */
 func VerifyEquation5(pairing *pbc.Pairing, proof *ProofOfEquation, U *pbc.Element, tau *pbc.Element, sigma *CommonReferenceString) bool {
+    if len(proof.c) != 1 || len(proof.d) != 2 || len(proof.Pi) != 2 || len(proof.Theta) != 2 {
+        return false
+    }
 
     // Construct LHS
     Uiota := Iota1(pairing, U)
@@ -749,22 +769,22 @@ func VerifyEquation5(pairing *pbc.Pairing, proof *ProofOfEquation, U *pbc.Elemen
  * u1 = (O, P)
  * u2 = t * u1
  */
-func CreateCommonReferenceString(sharedParams *SharedParams, alpha *pbc.Element) *CommonReferenceString {
+func CreateCommonReferenceString(sharedParams *SharedParams, alpha *pbc.Element, rand io.Reader) *CommonReferenceString {
     pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
 
     // Proof should use different generators then what is stored in the
     // params. Florian: Using the same generators could case security issues
     // due to the discrete logrihtm problem
     // Since the groups are cyclic, this should not matter.
-    g1 := pairing.NewG1().Rand()
-    g2 := pairing.NewG2().Rand()
+    g1 := randG1(pairing, rand)
+    g2 := randG2(pairing, rand)
     sigma := new(CommonReferenceString)
 
     // Create commit keys for u1 and u2 on G1
     u11 := g1.Bytes()
     u12 := pairing.NewG1().MulZn(g1, alpha)
 
-    t := pairing.NewZr().Rand()
+    t := randZr(pairing, rand)
     u21 := pairing.NewG1().MulZn(g1, t)
     u22 := pairing.NewG1().MulZn(u12, t)
 
@@ -777,7 +797,7 @@ func CreateCommonReferenceString(sharedParams *SharedParams, alpha *pbc.Element)
     v11 := g2.Bytes()
     v12 := pairing.NewG2().MulZn(g2, alpha)
 
-    t2 := pairing.NewZr().Rand()
+    t2 := randZr(pairing, rand)
     v21 := pairing.NewG2().MulZn(g2, t2)
     v22 := pairing.NewG2().MulZn(v12, t2)
 
@@ -804,12 +824,12 @@ func CreateCommonReferenceString(sharedParams *SharedParams, alpha *pbc.Element)
  * - Creates a commitment of a variable from G1 to B1
  *   c := ι1(X) + Ru
  */
-func CreateCommitmentOnG1(pairing *pbc.Pairing, chi []*pbc.Element, sigma *CommonReferenceString) ([]*BPair, []*BPair, *RMatrix){
+func CreateCommitmentOnG1(pairing *pbc.Pairing, chi []*pbc.Element, sigma *CommonReferenceString, rand io.Reader) ([]*BPair, []*BPair, *RMatrix){
 
     // Create RMatrix of random elements
     rows := len(chi)
     cols := len(sigma.U)
-    rmat := NewRMatrix(pairing, rows, cols)
+    rmat := NewRMatrix(pairing, rows, cols, rand)
 
     // Create pairs in B1
     Ru := rmat.MulCommitmentKeysG1(pairing, sigma.U)
@@ -837,12 +857,12 @@ func CreateCommitmentOnG1(pairing *pbc.Pairing, chi []*pbc.Element, sigma *Commo
  *
  *  x: Is in Zp
  */
-func CreateCommitmentPrimeOnG1(pairing *pbc.Pairing, x []*pbc.Element, sigma *CommonReferenceString) ([]*BPair, []*BPair, *RMatrix){
+func CreateCommitmentPrimeOnG1(pairing *pbc.Pairing, x []*pbc.Element, sigma *CommonReferenceString, rand io.Reader) ([]*BPair, []*BPair, *RMatrix){
 
     // Create RMatrix of random elements
     rows := len(x)
     cols := 1
-    rmat := NewRMatrix(pairing, rows, cols)
+    rmat := NewRMatrix(pairing, rows, cols, rand)
 
     // Create pairs in B1
     Ru := rmat.MulCommitmentKeysG1(pairing, []CommitmentKey{sigma.U[0]})
@@ -868,12 +888,12 @@ func CreateCommitmentPrimeOnG1(pairing *pbc.Pairing, x []*pbc.Element, sigma *Co
  * - Creates a commitment of a variable from G1 to B1
  *   c := ι1(X) + Ru
  */
-func CreateCommitmentOnG2(pairing *pbc.Pairing, Y []*pbc.Element, sigma *CommonReferenceString) ([]*BPair, []*BPair, *RMatrix){
+func CreateCommitmentOnG2(pairing *pbc.Pairing, Y []*pbc.Element, sigma *CommonReferenceString, rand io.Reader) ([]*BPair, []*BPair, *RMatrix){
 
     // Create RMatrix of random elements
     rows := len(Y)
     cols := len(sigma.V)
-    rmat := NewRMatrix(pairing, rows, cols)
+    rmat := NewRMatrix(pairing, rows, cols, rand)
 
     // Create pairs in B1
     Su := rmat.MulCommitmentKeysG2(pairing, sigma.V)
@@ -901,12 +921,12 @@ func CreateCommitmentOnG2(pairing *pbc.Pairing, Y []*pbc.Element, sigma *CommonR
  *
  *  x: Is in Zp
  */
-func CreateCommitmentPrimeOnG2(pairing *pbc.Pairing, y []*pbc.Element, sigma *CommonReferenceString) ([]*BPair, []*BPair, *RMatrix){
+func CreateCommitmentPrimeOnG2(pairing *pbc.Pairing, y []*pbc.Element, sigma *CommonReferenceString, rand io.Reader) ([]*BPair, []*BPair, *RMatrix){
 
     // Create RMatrix of random elements
     rows := len(y)
     cols := 1
-    rmat := NewRMatrix(pairing, rows, cols)
+    rmat := NewRMatrix(pairing, rows, cols, rand)
 
     // Create pairs in B1
     Su := rmat.MulCommitmentKeysG2(pairing, []CommitmentKey{sigma.V[0]})
@@ -0,0 +1,321 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * The Groth-Sahai proofs of the two pairing product equations of the
+ * DualAGHOScheme. Where the AGHO equations commit to R and S in B1, the
+ * dual ones commit to R, S and PKc in B2 and to T, C and D in B1.
+ */
+
+package ocert
+
+import (
+    "github.com/Nik-U/pbc"
+    "io"
+    "reflect"
+)
+
+/*
+ * Proof of the dual equation 4: e(V, R) * e(g1, S) * e(U, PKc) = e(Z, g2)
+ *   Pairing product equation, linear in the variables R, S, PKc from G2
+ *   with constants V, g1, U from G1
+ *
+ * Proof:
+ *    d      := ι_2(Y) + S*v
+ *    Pi     := -T'*v
+ *    Theta  := S'*ι_1(A) + T*u
+ */
+func ProveDualEquation4(pairing *pbc.Pairing,
+    R *pbc.Element,
+    S *pbc.Element,
+    PKc *pbc.Element,
+    V *pbc.Element,
+    G *pbc.Element,
+    U *pbc.Element,
+    sigma *CommonReferenceString,
+    rand io.Reader) *ProofOfEquation {
+    proof := new(ProofOfEquation)
+
+    // Create commitment in B2 for R, S, PKc
+    Yvec := []*pbc.Element{R, S, PKc}
+    d, _, Smat := CreateCommitmentOnG2(pairing, Yvec, sigma, rand)
+    if Smat.rows != 3 || Smat.cols != 2 {
+        panic("Issues in conversion and creation of samples in Zp for S")
+    }
+
+    //////////////////////////////////
+    // PI: for G2
+    /////////////////////////////////////
+    Tmat := NewRMatrix(pairing, 2, 2, rand)
+    negTi := Tmat.InvertMatrix().MulScalarZn(pairing, pairing.NewZr().Neg(pairing.NewZr().Set1()))
+    pi := negTi.MulCommitmentKeysG2(pairing, sigma.V)
+    if len(pi) != 2 {
+        panic("Issue in commitment key multiplication.")
+    }
+
+    //////////////////////////////////
+    // Theta: for G1
+    /////////////////////////////////////
+    Si := Smat.InvertMatrix()
+    Amat := new(BMatrix)
+    Amat.mat = [][]*BPair{
+        {Iota1(pairing, V)},
+        {Iota1(pairing, G)},
+        {Iota1(pairing, U)},
+    }
+    Amat.rows = 3
+    Amat.cols = 1
+
+    SiA := Si.MultBPairMatrixG1(pairing, Amat)
+    if SiA.rows != 2 || SiA.cols != 1 {
+        panic("SiA dimensionality is wrong. Needs to be 2x1")
+    }
+    // +
+    Tu := Tmat.MulCommitmentKeysG1(pairing, sigma.U)
+    if len(Tu) != 2 {
+        panic("Tu dimensionality is wrong. Needs to be len 2")
+    }
+    // =
+    theta := []*BPair{}
+    for i := 0; i < len(Tu); i++ {
+        theta = append(theta, SiA.mat[i][0].AddinG1(pairing, Tu[i]))
+    }
+
+    proof.d = d
+    proof.Pi = pi
+    proof.Theta = theta
+    return proof
+}
+
+/*
+ * Proof of the dual equation 5: e(T, R) * e(C, W1) * e(D, W2) = tau
+ *   Pairing product equation, quadratic in T from G1 and R from G2 and
+ *   linear in the variables C, D from G1 with constants W1, W2 from G2
+ *
+ * Proof:
+ *    c      := ι_1(X) + R*u,  X = (T, C, D)
+ *    d      := ι_2(Y) + S*v,  Y = (R)
+ *    Pi     := R'*ι_2(B) + R'*Gamma*ι_2(Y) + (R'*Gamma*S - T')*v
+ *    Theta  := S'*Gamma'*ι_1(X) + T*u
+ */
+func ProveDualEquation5(pairing *pbc.Pairing,
+    T *pbc.Element,
+    C *pbc.Element,
+    D *pbc.Element,
+    R *pbc.Element,
+    W1 *pbc.Element,
+    W2 *pbc.Element,
+    sigma *CommonReferenceString,
+    rand io.Reader) *ProofOfEquation {
+    proof := new(ProofOfEquation)
+
+    // Create commitment in B1 for T, C, D
+    c, _, Rmat := CreateCommitmentOnG1(pairing, []*pbc.Element{T, C, D}, sigma, rand)
+    if Rmat.rows != 3 || Rmat.cols != 2 {
+        panic("Issues in conversion and creation of samples in Zp for R")
+    }
+
+    // Create commitment in B2 for R
+    d, _, Smat := CreateCommitmentOnG2(pairing, []*pbc.Element{R}, sigma, rand)
+    if Smat.rows != 1 || Smat.cols != 2 {
+        panic("Issues in conversion and creation of samples in Zp for S")
+    }
+
+    // Gamma pairs T with R
+    Gamma := new(RMatrix)
+    Gamma.mat = [][]*pbc.Element{
+        {pairing.NewZr().Set1()},
+        {pairing.NewZr().Set0()},
+        {pairing.NewZr().Set0()},
+    }
+    Gamma.rows = 3
+    Gamma.cols = 1
+
+    //////////////////////////////////
+    // PI: for G2
+    /////////////////////////////////////
+    Ri := Rmat.InvertMatrix()
+    Tmat := NewRMatrix(pairing, 2, 2, rand)
+    Ti := Tmat.InvertMatrix()
+
+    // ι_2(B) + Gamma*ι_2(Y), B = (0, W1, W2)
+    BY := new(BMatrix)
+    BY.mat = [][]*BPair{
+        {Iota2(pairing, R)},
+        {Iota2(pairing, W1)},
+        {Iota2(pairing, W2)},
+    }
+    BY.rows = 3
+    BY.cols = 1
+    RBY := Ri.MultBPairMatrixG2(pairing, BY)
+    if RBY.rows != 2 || RBY.cols != 1 {
+        panic("Issue in dimensionality when mult in B matrix")
+    }
+
+    // + (
+
+    GS := Gamma.MultElementArrayZr(pairing, Smat.mat)
+    RGS := Ri.MultElementArrayZr(pairing, GS.mat)
+    RGST := RGS.ElementWiseSub(pairing, Ti)
+    RGSTv := RGST.MulCommitmentKeysG2(pairing, sigma.V)
+    if len(RGSTv) != 2 {
+        panic("RGSTv dimensionality is incorrect should be 2")
+    }
+
+    // ) =  Construct pi
+    pi := []*BPair{}
+    for i := 0; i < len(RGSTv); i++ {
+        pi = append(pi, RBY.mat[i][0].AddinG2(pairing, RGSTv[i]))
+    }
+
+    //////////////////////////////////
+    // Theta: for G1
+    /////////////////////////////////////
+    // Gamma'*ι_1(X) = ι_1(T)
+    Si := Smat.InvertMatrix()
+    SX := Si.MulBScalarinB1(pairing, *Iota1(pairing, T))
+    if len(SX) != 2 || len(SX[0]) != 1 {
+        panic("SX dimensionality is wrong. Needs to be 2x1")
+    }
+    // +
+    Tu := Tmat.MulCommitmentKeysG1(pairing, sigma.U)
+    if len(Tu) != 2 {
+        panic("Tu dimensionality is wrong. Needs to be len 2")
+    }
+    // =
+    theta := []*BPair{}
+    for i := 0; i < len(Tu); i++ {
+        theta = append(theta, SX[i][0].AddinG1(pairing, Tu[i]))
+    }
+
+    proof.c = c
+    proof.d = d
+    proof.Pi = pi
+    proof.Theta = theta
+    return proof
+}
+
+/*
+ * The right hand side shared by all pairing product equations:
+ * ι_T(tau) + u*Pi + Theta*v
+ */
+func verifyEquationRHS(pairing *pbc.Pairing, proof *ProofOfEquation, tau *pbc.Element, sigma *CommonReferenceString) *BTMat {
+    RHS := IotaT(pairing, tau)
+    for i := 0; i < 2; i++ {
+        RHS = RHS.AddinGT(pairing, FMap(pairing, sigma.U[i].ConvertToBPair(), proof.Pi[i]))
+    }
+    for i := 0; i < 2; i++ {
+        RHS = RHS.AddinGT(pairing, FMap(pairing, proof.Theta[i], sigma.V[i].ConvertToBPair()))
+    }
+    return RHS
+}
+
+/*
+ * Verify the dual equation 4, tau = e(Z, g2)
+ */
+func VerifyDualEquation4(
+    pairing *pbc.Pairing,
+    proof *ProofOfEquation,
+    V *pbc.Element,
+    G *pbc.Element,
+    U *pbc.Element,
+    tau *pbc.Element,
+    sigma *CommonReferenceString) bool {
+    if len(proof.d) != 3 || len(proof.Pi) != 2 || len(proof.Theta) != 2 {
+        return false
+    }
+
+    // Construct LHS
+    LHS := FMap(pairing, Iota1(pairing, V), proof.d[0])
+    LHS = LHS.AddinGT(pairing, FMap(pairing, Iota1(pairing, G), proof.d[1]))
+    LHS = LHS.AddinGT(pairing, FMap(pairing, Iota1(pairing, U), proof.d[2]))
+
+    return reflect.DeepEqual(LHS, verifyEquationRHS(pairing, proof, tau, sigma))
+}
+
+/*
+ * Verify the dual equation 5, tau = e(g1, g2) / e(E, W3)
+ */
+func VerifyDualEquation5(
+    pairing *pbc.Pairing,
+    proof *ProofOfEquation,
+    W1 *pbc.Element,
+    W2 *pbc.Element,
+    tau *pbc.Element,
+    sigma *CommonReferenceString) bool {
+    if len(proof.c) != 3 || len(proof.d) != 1 || len(proof.Pi) != 2 || len(proof.Theta) != 2 {
+        return false
+    }
+
+    // Construct LHS
+    LHS := FMap(pairing, proof.c[0], proof.d[0])
+    LHS = LHS.AddinGT(pairing, FMap(pairing, proof.c[1], Iota2(pairing, W1)))
+    LHS = LHS.AddinGT(pairing, FMap(pairing, proof.c[2], Iota2(pairing, W2)))
+
+    return reflect.DeepEqual(LHS, verifyEquationRHS(pairing, proof, tau, sigma))
+}
+
+/*
+ * Set up the proofs of both dual equations for PSetup
+ */
+func proveDualECert(pairing *pbc.Pairing, sharedParams *SharedParams, vars *ProofVariables, sigma *CommonReferenceString, rand io.Reader) (*ProofOfEquation, *ProofOfEquation) {
+    G := pairing.NewG1().SetBytes(sharedParams.G1)
+
+    // Vars
+    R := pairing.NewG2().SetBytes(vars.E.R)
+    S := pairing.NewG2().SetBytes(vars.E.S)
+    T := pairing.NewG1().SetBytes(vars.E.T)
+    PKc := pairing.NewG2().SetBytes(vars.PKc.PK)
+    C := pairing.NewG1().SetBytes(vars.P.C)
+    D := pairing.NewG1().SetBytes(vars.P.D)
+
+    // Constants
+    U := pairing.NewG1().SetBytes(vars.VK.U)
+    V := pairing.NewG1().SetBytes(vars.VK.V)
+    W1 := pairing.NewG2().SetBytes(vars.VK.W1)
+    W2 := pairing.NewG2().SetBytes(vars.VK.W2)
+
+    eq4 := ProveDualEquation4(pairing, R, S, PKc, V, G, U, sigma, rand)
+    eq5 := ProveDualEquation5(pairing, T, C, D, R, W1, W2, sigma, rand)
+    return eq4, eq5
+}
+
+/*
+ * Validate both dual equations for PProve
+ */
+func verifyDualECert(pairing *pbc.Pairing, sharedParams *SharedParams, pi *ProofOfKnowledge, consts *ProofConstants) bool {
+    G := pairing.NewG1().SetBytes(sharedParams.G1)
+    U := pairing.NewG1().SetBytes(consts.VK.U)
+    V := pairing.NewG1().SetBytes(consts.VK.V)
+    W1 := pairing.NewG2().SetBytes(consts.VK.W1)
+    W2 := pairing.NewG2().SetBytes(consts.VK.W2)
+
+    eZH := pairing.NewGT().SetBytes(consts.Egz)
+    if !VerifyDualEquation4(pairing, pi.Eq4, V, G, U, eZH, pi.sigma) {
+        return false
+    }
+
+    eGH := pairing.NewGT().SetBytes(consts.Egh)
+    if consts.Epoch != nil {
+        // The ecert signs the current epoch E, move e(E, W3) to the target
+        E := pairing.NewG1().SetBytes(consts.Epoch)
+        W3 := pairing.NewG2().SetBytes(consts.VK.W3)
+        eGH = pairing.NewGT().Div(eGH, pairing.NewGT().Pair(E, W3))
+    }
+    return VerifyDualEquation5(pairing, pi.Eq5, W1, W2, eGH, pi.sigma)
+}
@@ -0,0 +1,792 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "io"
+    "testing"
+    "testing/quick"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * Property tests draw their elements from a PRF of the seed chosen by
+ * testing/quick, so a failing seed reproduces the counterexample
+ */
+func propertyRand(seed [16]byte) io.Reader {
+    return NewPRFReader(seed[:], []byte("property"))
+}
+
+func checkProperty(t *testing.T, property func(seed [16]byte) bool) {
+    config := &quick.Config{MaxCount: 20}
+    if testing.Short() {
+        config.MaxCount = 3
+    }
+    err := quick.Check(property, config)
+    if err != nil {
+        t.Error(err)
+    }
+}
+
+func btMatEquals(pairing *pbc.Pairing, A *BTMat, B *BTMat) bool {
+    return pairing.NewGT().SetBytes(A.el11).Equals(pairing.NewGT().SetBytes(B.el11)) &&
+        pairing.NewGT().SetBytes(A.el12).Equals(pairing.NewGT().SetBytes(B.el12)) &&
+        pairing.NewGT().SetBytes(A.el21).Equals(pairing.NewGT().SetBytes(B.el21)) &&
+        pairing.NewGT().SetBytes(A.el22).Equals(pairing.NewGT().SetBytes(B.el22))
+}
+
+func btMatPowZn(pairing *pbc.Pairing, A *BTMat, a *pbc.Element) *BTMat {
+    B := new(BTMat)
+    B.el11 = pairing.NewGT().PowZn(pairing.NewGT().SetBytes(A.el11), a).Bytes()
+    B.el12 = pairing.NewGT().PowZn(pairing.NewGT().SetBytes(A.el12), a).Bytes()
+    B.el21 = pairing.NewGT().PowZn(pairing.NewGT().SetBytes(A.el21), a).Bytes()
+    B.el22 = pairing.NewGT().PowZn(pairing.NewGT().SetBytes(A.el22), a).Bytes()
+    return B
+}
+
+/*
+ * Rho inverts Iota for every element and every CRS trapdoor
+ */
+func TestIotaRhoRoundTrip(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    t.Run("G1", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            X := randG1(pairing, rand)
+            return Rho1(pairing, Iota1(pairing, X), randZr(pairing, rand)).Equals(X)
+        })
+    })
+    t.Run("G2", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            Y := randG2(pairing, rand)
+            return Rho2(pairing, Iota2(pairing, Y), randZr(pairing, rand)).Equals(Y)
+        })
+    })
+}
+
+/*
+ * RhoPrime maps IotaPrime(z) to z times the generator of the CRS
+ */
+func TestIotaRhoPrimeRoundTrip(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    t.Run("G1", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            alpha := randZr(pairing, rand)
+            sigma := CreateCommonReferenceString(sharedParams, alpha, rand)
+            z := randZr(pairing, rand)
+            P := pairing.NewG1().SetBytes(sigma.U[0].u1)
+            return RhoPrime1(pairing, IotaPrime1(pairing, z, sigma), alpha).Equals(pairing.NewG1().MulZn(P, z))
+        })
+    })
+    t.Run("G2", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            alpha := randZr(pairing, rand)
+            sigma := CreateCommonReferenceString(sharedParams, alpha, rand)
+            z := randZr(pairing, rand)
+            P := pairing.NewG2().SetBytes(sigma.V[0].u1)
+            return RhoPrime2(pairing, IotaPrime2(pairing, z, sigma), alpha).Equals(pairing.NewG2().MulZn(P, z))
+        })
+    })
+}
+
+/*
+ * F: B1 x B2 -> BT pairs the components of its arguments
+ */
+func TestFMap(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    checkProperty(t, func(seed [16]byte) bool {
+        rand := propertyRand(seed)
+        X1, X2 := randG1(pairing, rand), randG1(pairing, rand)
+        Y1, Y2 := randG2(pairing, rand), randG2(pairing, rand)
+        BT := FMap(pairing, &BPair{X1.Bytes(), X2.Bytes()}, &BPair{Y1.Bytes(), Y2.Bytes()})
+
+        return pairing.NewGT().Pair(X1, Y1).Equals(pairing.NewGT().SetBytes(BT.el11)) &&
+            pairing.NewGT().Pair(X1, Y2).Equals(pairing.NewGT().SetBytes(BT.el12)) &&
+            pairing.NewGT().Pair(X2, Y1).Equals(pairing.NewGT().SetBytes(BT.el21)) &&
+            pairing.NewGT().Pair(X2, Y2).Equals(pairing.NewGT().SetBytes(BT.el22))
+    })
+}
+
+/*
+ * F is bilinear: scalars move between its arguments and out of it, and
+ * it is additive in each argument
+ */
+func TestFMapBilinear(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    randB1 := func(rand io.Reader) *BPair {
+        return &BPair{randG1(pairing, rand).Bytes(), randG1(pairing, rand).Bytes()}
+    }
+    randB2 := func(rand io.Reader) *BPair {
+        return &BPair{randG2(pairing, rand).Bytes(), randG2(pairing, rand).Bytes()}
+    }
+
+    t.Run("Scalar", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            B1, B2 := randB1(rand), randB2(rand)
+            a := randZr(pairing, rand)
+            aF := btMatPowZn(pairing, FMap(pairing, B1, B2), a)
+            return btMatEquals(pairing, FMap(pairing, B1.MulScalarInG1(pairing, a), B2), aF) &&
+                btMatEquals(pairing, FMap(pairing, B1, B2.MulScalarInG2(pairing, a)), aF)
+        })
+    })
+    t.Run("AddB1", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            B1, B1prime, B2 := randB1(rand), randB1(rand), randB2(rand)
+            sum := FMap(pairing, B1, B2).AddinGT(pairing, FMap(pairing, B1prime, B2))
+            return btMatEquals(pairing, FMap(pairing, B1.AddinG1(pairing, B1prime), B2), sum)
+        })
+    })
+    t.Run("AddB2", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            B1, B2, B2prime := randB1(rand), randB2(rand), randB2(rand)
+            sum := FMap(pairing, B1, B2).AddinGT(pairing, FMap(pairing, B1, B2prime))
+            return btMatEquals(pairing, FMap(pairing, B1, B2.AddinG2(pairing, B2prime)), sum)
+        })
+    })
+}
+
+/*
+ * The maps into BT commute with F:
+ *   ιT(e(X, Y)) = F(ι1(X), ι2(Y))
+ *   ι^T(xY) = F(ι'1(x), ι2(Y)) = F(ι'1(1), ι2(xY))
+ *   ι^T2(xX) = F(ι1(X), ι'2(x)) = F(ι1(xX), ι'2(1))
+ */
+func TestIotaHat(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    one := pairing.NewZr().Set1()
+
+    t.Run("PairingProduct", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            X := randG1(pairing, rand)
+            Y := randG2(pairing, rand)
+            BT := IotaT(pairing, ProductPairing_e_GT_map(pairing, X, Y))
+            return btMatEquals(pairing, BT, FMap(pairing, Iota1(pairing, X), Iota2(pairing, Y)))
+        })
+    })
+    t.Run("MultiScalarG2", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            sigma := CreateCommonReferenceString(sharedParams, randZr(pairing, rand), rand)
+            x := randZr(pairing, rand)
+            Y := randG2(pairing, rand)
+            xY := MultiScalar_f_G2_map(pairing, x, Y)
+            BT := IotaHat(pairing, xY, sigma)
+            return btMatEquals(pairing, BT, FMap(pairing, IotaPrime1(pairing, x, sigma), Iota2(pairing, Y))) &&
+                btMatEquals(pairing, BT, FMap(pairing, IotaPrime1(pairing, one, sigma), Iota2(pairing, xY)))
+        })
+    })
+    t.Run("MultiScalarG1", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            sigma := CreateCommonReferenceString(sharedParams, randZr(pairing, rand), rand)
+            x := randZr(pairing, rand)
+            X := randG1(pairing, rand)
+            xX := MultiScalar_f_G1_map(pairing, x, X)
+            BT := IotaHat2(pairing, xX, sigma)
+            return btMatEquals(pairing, BT, FMap(pairing, Iota1(pairing, X), IotaPrime2(pairing, x, sigma))) &&
+                btMatEquals(pairing, BT, FMap(pairing, Iota1(pairing, xX), IotaPrime2(pairing, one, sigma)))
+        })
+    })
+}
+
+/*
+ * The commitment functions for variables in G1, G2 and Zp
+ */
+type commitmentCase struct {
+    name string
+    newElement func() *pbc.Element
+    newVariable func() *pbc.Element
+    keys []CommitmentKey
+    commit func(*pbc.Pairing, []*pbc.Element, *CommonReferenceString, io.Reader) ([]*BPair, []*BPair, *RMatrix)
+    iota func(*pbc.Element) *BPair
+}
+
+func commitmentCases(pairing *pbc.Pairing, sigma *CommonReferenceString) []commitmentCase {
+    return []commitmentCase{
+        {
+            "G1", pairing.NewG1, pairing.NewG1, sigma.U, CreateCommitmentOnG1,
+            func(X *pbc.Element) *BPair { return Iota1(pairing, X) },
+        },
+        {
+            "PrimeOnG1", pairing.NewG1, pairing.NewZr, sigma.U[:1], CreateCommitmentPrimeOnG1,
+            func(x *pbc.Element) *BPair { return IotaPrime1(pairing, x, sigma) },
+        },
+        {
+            "G2", pairing.NewG2, pairing.NewG2, sigma.V, CreateCommitmentOnG2,
+            func(Y *pbc.Element) *BPair { return Iota2(pairing, Y) },
+        },
+        {
+            "PrimeOnG2", pairing.NewG2, pairing.NewZr, sigma.V[:1], CreateCommitmentPrimeOnG2,
+            func(y *pbc.Element) *BPair { return IotaPrime2(pairing, y, sigma) },
+        },
+    }
+}
+
+/*
+ * A commitment is ι(X) + Ru, with Ru the randomness matrix times the
+ * commitment keys
+ */
+func TestCreateCommitment(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    alpha := pairing.NewZr().Rand()
+    sigma := CreateCommonReferenceString(sharedParams, alpha, nil)
+
+    for _, tc := range commitmentCases(pairing, sigma) {
+        t.Run(tc.name, func(t *testing.T) {
+            chi := make([]*pbc.Element, 15)
+            for i := range chi {
+                chi[i] = tc.newVariable().Rand()
+            }
+            C, Ru, rmat := tc.commit(pairing, chi, sigma, nil)
+            if len(C) != len(chi) || len(Ru) != len(chi) {
+                t.Fatalf("%d variables, %d commitments, %d randomizers", len(chi), len(C), len(Ru))
+            }
+            checkRMatrixStructure(t, rmat)
+
+            for i := range C {
+                r1 := tc.newElement().Set0()
+                r2 := tc.newElement().Set0()
+                for j, key := range tc.keys {
+                    r1.ThenAdd(tc.newElement().MulZn(tc.newElement().SetBytes(key.u1), rmat.mat[i][j]))
+                    r2.ThenAdd(tc.newElement().MulZn(tc.newElement().SetBytes(key.u2), rmat.mat[i][j]))
+                }
+                if !r1.Equals(tc.newElement().SetBytes(Ru[i].b1)) || !r2.Equals(tc.newElement().SetBytes(Ru[i].b2)) {
+                    t.Errorf("randomizer %d is not R * u", i)
+                }
+
+                b1 := tc.newElement().Sub(tc.newElement().SetBytes(C[i].b1), r1)
+                b2 := tc.newElement().Sub(tc.newElement().SetBytes(C[i].b2), r2)
+                B := tc.iota(chi[i])
+                if !b1.Equals(tc.newElement().SetBytes(B.b1)) || !b2.Equals(tc.newElement().SetBytes(B.b2)) {
+                    t.Errorf("commitment %d minus its randomizer is not ι(X)", i)
+                }
+            }
+        })
+    }
+}
+
+/*
+ * With the trapdoor alpha of the CRS a commitment opens to its variable,
+ * times the generator for variables in Zp
+ */
+func TestCommitmentOpens(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    t.Run("G1", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            alpha := randZr(pairing, rand)
+            sigma := CreateCommonReferenceString(sharedParams, alpha, rand)
+            X := randG1(pairing, rand)
+            C, _, _ := CreateCommitmentOnG1(pairing, []*pbc.Element{X}, sigma, rand)
+            return Rho1(pairing, C[0], alpha).Equals(X)
+        })
+    })
+    t.Run("PrimeOnG1", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            alpha := randZr(pairing, rand)
+            sigma := CreateCommonReferenceString(sharedParams, alpha, rand)
+            x := randZr(pairing, rand)
+            C, _, _ := CreateCommitmentPrimeOnG1(pairing, []*pbc.Element{x}, sigma, rand)
+            P := pairing.NewG1().SetBytes(sigma.U[0].u1)
+            return RhoPrime1(pairing, C[0], alpha).Equals(pairing.NewG1().MulZn(P, x))
+        })
+    })
+    t.Run("G2", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            alpha := randZr(pairing, rand)
+            sigma := CreateCommonReferenceString(sharedParams, alpha, rand)
+            Y := randG2(pairing, rand)
+            C, _, _ := CreateCommitmentOnG2(pairing, []*pbc.Element{Y}, sigma, rand)
+            return Rho2(pairing, C[0], alpha).Equals(Y)
+        })
+    })
+    t.Run("PrimeOnG2", func(t *testing.T) {
+        checkProperty(t, func(seed [16]byte) bool {
+            rand := propertyRand(seed)
+            alpha := randZr(pairing, rand)
+            sigma := CreateCommonReferenceString(sharedParams, alpha, rand)
+            y := randZr(pairing, rand)
+            C, _, _ := CreateCommitmentPrimeOnG2(pairing, []*pbc.Element{y}, sigma, rand)
+            P := pairing.NewG2().SetBytes(sigma.V[0].u1)
+            return RhoPrime2(pairing, C[0], alpha).Equals(pairing.NewG2().MulZn(P, y))
+        })
+    })
+}
+
+/*
+ * Everything a client proves knowledge of, and what the issuer verifies
+ * it against. The ecert does not sign an epoch.
+ */
+type proofFixture struct {
+    sharedParams *SharedParams
+    pairing *pbc.Pairing
+    sigma *CommonReferenceString
+    vars *ProofVariables
+    consts *ProofConstants
+}
+
+func newProofFixture() *proofFixture {
+    f := new(proofFixture)
+    f.sharedParams = GenerateSharedParams()
+    f.pairing, _ = pbc.NewPairingFromString(f.sharedParams.Params)
+    pairing := f.pairing
+    G := pairing.NewG1().SetBytes(f.sharedParams.G1)
+    H := pairing.NewG2().SetBytes(f.sharedParams.G2)
+    f.sigma = CreateCommonReferenceString(f.sharedParams, pairing.NewZr().Rand(), nil)
+
+    VK, SK := SKeyGen(f.sharedParams, nil)
+    PKa, _ := EKeyGen(f.sharedParams, nil)
+    clientID := new(ClientID)
+    clientID.ID = pairing.NewG1().Rand().Bytes()
+    P := EEnc(f.sharedParams, PKa, clientID, nil)
+    Pprime, rprime := ERerand(f.sharedParams, PKa, P, nil)
+
+    PKc := new(ClientPublicKey)
+    Xc := pairing.NewZr().Rand().Bytes()
+    PKc.PK = pairing.NewG2().MulZn(H, pairing.NewZr().SetBytes(Xc)).Bytes()
+
+    f.vars = new(ProofVariables)
+    f.vars.PKa = PKa
+    f.vars.P = P
+    f.vars.VK = VK
+    f.vars.RPrime = rprime
+    f.vars.PKc = PKc
+    f.vars.Xc = Xc
+    f.vars.E = SSign(f.sharedParams, SK, P, PKc, nil, nil)
+
+    f.consts = new(ProofConstants)
+    f.consts.VK = VK
+    f.consts.PKa = PKa
+    f.consts.Egh = pairing.NewGT().Pair(G, H).Bytes()
+    f.consts.Egz = pairing.NewGT().Pair(G, pairing.NewG2().SetBytes(VK.Z)).Bytes()
+    f.consts.PPrime = Pprime
+    return f
+}
+
+/*
+ * One equation of the proof of knowledge, with the number of elements of
+ * each part of its proof
+ */
+type equationCase struct {
+    name string
+    prove func(rand io.Reader) *ProofOfEquation
+    verify func(proof *ProofOfEquation) bool
+    theta, pi, c, d, cprime, dprime int
+}
+
+func (f *proofFixture) equationCases() []equationCase {
+    pairing := f.pairing
+    sigma := f.sigma
+    G := pairing.NewG1().SetBytes(f.sharedParams.G1)
+    H := pairing.NewG2().SetBytes(f.sharedParams.G2)
+
+    Xc := pairing.NewZr().SetBytes(f.vars.Xc)
+    negPKc := pairing.NewG2().Neg(pairing.NewG2().SetBytes(f.vars.PKc.PK))
+    r := pairing.NewZr().SetBytes(f.vars.RPrime)
+    C := pairing.NewG1().SetBytes(f.vars.P.C)
+    D := pairing.NewG1().SetBytes(f.vars.P.D)
+    PKa := pairing.NewG1().SetBytes(f.vars.PKa.PK)
+    Cprime := pairing.NewG1().SetBytes(f.consts.PPrime.C)
+    Dprime := pairing.NewG1().SetBytes(f.consts.PPrime.D)
+
+    R := pairing.NewG1().SetBytes(f.vars.E.R)
+    S := pairing.NewG1().SetBytes(f.vars.E.S)
+    T := pairing.NewG2().SetBytes(f.vars.E.T)
+    PKc := pairing.NewG2().SetBytes(f.vars.PKc.PK)
+    U := pairing.NewG1().SetBytes(f.vars.VK.U)
+    V := pairing.NewG2().SetBytes(f.vars.VK.V)
+    W1 := pairing.NewG2().SetBytes(f.vars.VK.W1)
+    W2 := pairing.NewG2().SetBytes(f.vars.VK.W2)
+    eGZ := pairing.NewGT().SetBytes(f.consts.Egz)
+    eGH := pairing.NewGT().SetBytes(f.consts.Egh)
+
+    return []equationCase{
+        {
+            "Eq1",
+            func(rand io.Reader) *ProofOfEquation { return ProveEquation1(pairing, Xc, H, negPKc, sigma, rand) },
+            func(proof *ProofOfEquation) bool { return VerifyEquation1(pairing, proof, H, pairing.NewG2().Set0(), sigma) },
+            2, 1, 0, 1, 1, 0,
+        },
+        {
+            "Eq2",
+            func(rand io.Reader) *ProofOfEquation { return ProveEquation2(pairing, r, G, C, sigma, rand) },
+            func(proof *ProofOfEquation) bool { return VerifyEquation2(pairing, proof, G, Cprime, sigma) },
+            1, 2, 1, 0, 0, 1,
+        },
+        {
+            "Eq3",
+            func(rand io.Reader) *ProofOfEquation { return ProveEquation2(pairing, r, PKa, D, sigma, rand) },
+            func(proof *ProofOfEquation) bool { return VerifyEquation2(pairing, proof, PKa, Dprime, sigma) },
+            1, 2, 1, 0, 0, 1,
+        },
+        {
+            "Eq4",
+            func(rand io.Reader) *ProofOfEquation { return ProveEquation4(pairing, R, S, C, D, V, H, W1, W2, sigma, rand) },
+            func(proof *ProofOfEquation) bool { return VerifyEquation4(pairing, proof, V, H, W1, W2, eGZ, sigma) },
+            2, 2, 4, 0, 0, 0,
+        },
+        {
+            "Eq5",
+            func(rand io.Reader) *ProofOfEquation { return ProveEquation5(pairing, R, T, PKc, U, sigma, rand) },
+            func(proof *ProofOfEquation) bool { return VerifyEquation5(pairing, proof, U, eGH, sigma) },
+            2, 2, 1, 2, 0, 0,
+        },
+    }
+}
+
+func checkEquationStructure(t *testing.T, tc equationCase, proof *ProofOfEquation) {
+    if len(proof.Theta) != tc.theta || len(proof.Pi) != tc.pi ||
+        len(proof.c) != tc.c || len(proof.d) != tc.d ||
+        len(proof.cprime) != tc.cprime || len(proof.dprime) != tc.dprime {
+        t.Errorf("Theta %d, Pi %d, c %d, d %d, c' %d, d' %d; want %d, %d, %d, %d, %d, %d",
+            len(proof.Theta), len(proof.Pi), len(proof.c), len(proof.d), len(proof.cprime), len(proof.dprime),
+            tc.theta, tc.pi, tc.c, tc.d, tc.cprime, tc.dprime)
+    }
+}
+
+/*
+ * The witnesses of the fixture satisfy the equations: the ecert verifies
+ * and P' is a rerandomization of P with r'
+ */
+func TestEquationWitnesses(t *testing.T) {
+    f := newProofFixture()
+    pairing := f.pairing
+    G := pairing.NewG1().SetBytes(f.sharedParams.G1)
+    H := pairing.NewG2().SetBytes(f.sharedParams.G2)
+    r := pairing.NewZr().SetBytes(f.vars.RPrime)
+
+    if !SVerify(f.sharedParams, f.vars.VK, f.vars.P, f.vars.PKc, nil, f.vars.E) {
+        t.Error("ecert does not verify")
+    }
+    R := pairing.NewG1().SetBytes(f.vars.E.R)
+    T := pairing.NewG2().SetBytes(f.vars.E.T)
+    U := pairing.NewG1().SetBytes(f.vars.VK.U)
+    PKc := pairing.NewG2().SetBytes(f.vars.PKc.PK)
+    eq5 := pairing.NewGT().Mul(pairing.NewGT().Pair(R, T), pairing.NewGT().Pair(U, PKc))
+    if !eq5.Equals(pairing.NewGT().Pair(G, H)) {
+        t.Error("e(R, T)e(U, PKc) != e(G, H)")
+    }
+
+    Cprime := pairing.NewG1().Add(pairing.NewG1().SetBytes(f.vars.P.C), pairing.NewG1().MulZn(G, r))
+    if !Cprime.Equals(pairing.NewG1().SetBytes(f.consts.PPrime.C)) {
+        t.Error("C + r'G != C'")
+    }
+    PKa := pairing.NewG1().SetBytes(f.vars.PKa.PK)
+    Dprime := pairing.NewG1().Add(pairing.NewG1().SetBytes(f.vars.P.D), pairing.NewG1().MulZn(PKa, r))
+    if !Dprime.Equals(pairing.NewG1().SetBytes(f.consts.PPrime.D)) {
+        t.Error("D + r'PKa != D'")
+    }
+}
+
+func TestProveEquation(t *testing.T) {
+    f := newProofFixture()
+    for _, tc := range f.equationCases() {
+        t.Run(tc.name, func(t *testing.T) {
+            proof := tc.prove(nil)
+            checkEquationStructure(t, tc, proof)
+            if !tc.verify(proof) {
+                t.Error("valid proof rejected")
+            }
+        })
+    }
+}
+
+/*
+ * A proof whose Theta or Pi is taken from another proof of the same
+ * statement no longer matches its commitments
+ */
+func TestVerifyEquationTampered(t *testing.T) {
+    f := newProofFixture()
+    for _, tc := range f.equationCases() {
+        t.Run(tc.name, func(t *testing.T) {
+            other := tc.prove(nil)
+            proof := tc.prove(nil)
+            proof.Theta[0] = other.Theta[0]
+            if tc.verify(proof) {
+                t.Error("proof with a foreign Theta accepted")
+            }
+
+            proof = tc.prove(nil)
+            proof.Pi[0] = other.Pi[0]
+            if tc.verify(proof) {
+                t.Error("proof with a foreign Pi accepted")
+            }
+        })
+    }
+}
+
+/*
+ * The whole proof of knowledge, set up by the client and verified by the
+ * issuer
+ */
+func TestPSetupPProve(t *testing.T) {
+    f := newProofFixture()
+    pi := PSetup(f.sharedParams, f.vars, nil)
+
+    equations := []*ProofOfEquation{pi.Eq1, pi.Eq2, pi.Eq3, pi.Eq4, pi.Eq5}
+    for i, tc := range f.equationCases() {
+        t.Run(tc.name, func(t *testing.T) {
+            checkEquationStructure(t, tc, equations[i])
+        })
+    }
+    if !PProve(f.sharedParams, pi, f.consts) {
+        t.Error("valid proof of knowledge rejected")
+    }
+}
+
+/*
+ * The issuer rejects a proof of knowledge with an equation from another
+ * proof, for another rerandomized pseudonym or under another verification
+ * key
+ */
+func TestPProveTampered(t *testing.T) {
+    f := newProofFixture()
+    pi := PSetup(f.sharedParams, f.vars, nil)
+    other := PSetup(f.sharedParams, f.vars, nil)
+
+    equations := []**ProofOfEquation{&pi.Eq1, &pi.Eq2, &pi.Eq3, &pi.Eq4, &pi.Eq5}
+    foreign := []*ProofOfEquation{other.Eq1, other.Eq2, other.Eq3, other.Eq4, other.Eq5}
+    for i, tc := range f.equationCases() {
+        t.Run(tc.name, func(t *testing.T) {
+            eq := *equations[i]
+            *equations[i] = foreign[i]
+            defer func() { *equations[i] = eq }()
+            if PProve(f.sharedParams, pi, f.consts) {
+                t.Error("proof with a foreign equation accepted")
+            }
+        })
+    }
+
+    t.Run("PPrime", func(t *testing.T) {
+        PPrime := f.consts.PPrime
+        defer func() { f.consts.PPrime = PPrime }()
+        f.consts.PPrime, _ = ERerand(f.sharedParams, f.vars.PKa, f.vars.P, nil)
+        if PProve(f.sharedParams, pi, f.consts) {
+            t.Error("proof for another pseudonym accepted")
+        }
+    })
+    t.Run("VK", func(t *testing.T) {
+        VK, Egz := f.consts.VK, f.consts.Egz
+        defer func() { f.consts.VK, f.consts.Egz = VK, Egz }()
+        f.consts.VK, _ = SKeyGen(f.sharedParams, nil)
+        f.consts.Egz = f.pairing.NewGT().Pair(f.pairing.NewG1().SetBytes(f.sharedParams.G1),
+            f.pairing.NewG2().SetBytes(f.consts.VK.Z)).Bytes()
+        if PProve(f.sharedParams, pi, f.consts) {
+            t.Error("proof under another verification key accepted")
+        }
+    })
+}
+
+/*
+ * Sign the fixture's ecert with a fresh key of the scheme for the epoch
+ */
+func (f *proofFixture) useScheme(scheme SPSScheme, epoch *Epoch) {
+    VK, SK := scheme.KeyGen(f.sharedParams, nil)
+    f.vars.VK = VK
+    f.vars.E = scheme.Sign(f.sharedParams, SK, f.vars.P, f.vars.PKc, epoch, nil)
+    f.consts.VK = VK
+    f.consts.Egz = SPSEgz(f.sharedParams, VK)
+    f.consts.Epoch = nil
+    if epoch != nil {
+        f.consts.Epoch = epoch.E
+    }
+}
+
+/*
+ * The proof of knowledge of a Dual AGHO ecert, which has other equations
+ * than AGHO, and is rejected under a key of another scheme
+ */
+func TestPSetupPProveDual(t *testing.T) {
+    f := newProofFixture()
+    epoch := NewEpoch(f.sharedParams, 2)
+    f.useScheme(new(DualAGHOScheme), epoch)
+    pi := PSetup(f.sharedParams, f.vars, nil)
+
+    if len(pi.Eq4.d) != 3 || len(pi.Eq5.c) != 3 || len(pi.Eq5.d) != 1 {
+        t.Errorf("unexpected commitments %d, %d and %d", len(pi.Eq4.d), len(pi.Eq5.c), len(pi.Eq5.d))
+    }
+    if !PProve(f.sharedParams, pi, f.consts) {
+        t.Error("valid proof of knowledge rejected")
+    }
+
+    t.Run("Epoch", func(t *testing.T) {
+        defer func() { f.consts.Epoch = epoch.E }()
+        f.consts.Epoch = NewEpoch(f.sharedParams, 3).E
+        if PProve(f.sharedParams, pi, f.consts) {
+            t.Error("expired epoch accepted")
+        }
+    })
+    t.Run("Eq5", func(t *testing.T) {
+        other := PSetup(f.sharedParams, f.vars, nil)
+        eq := pi.Eq5
+        defer func() { pi.Eq5 = eq }()
+        pi.Eq5 = other.Eq4
+        if PProve(f.sharedParams, pi, f.consts) {
+            t.Error("proof with a foreign equation accepted")
+        }
+    })
+    t.Run("Scheme", func(t *testing.T) {
+        VK, Egz := f.consts.VK, f.consts.Egz
+        defer func() { f.consts.VK, f.consts.Egz = VK, Egz }()
+        f.consts.VK, _ = SKeyGen(f.sharedParams, nil)
+        f.consts.Egz = SPSEgz(f.sharedParams, f.consts.VK)
+        if PProve(f.sharedParams, pi, f.consts) {
+            t.Error("proof under a key of another scheme accepted")
+        }
+    })
+}
+
+/*
+ * Both schemes commit to seven group elements, but Dual AGHO commits to
+ * more of G2, whose elements are larger on the curve of
+ * GenerateSharedParams
+ */
+func TestSPSProofSize(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    agho, err := SPSProofSize(sharedParams, new(AGHOScheme), nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    dual, err := SPSProofSize(sharedParams, new(DualAGHOScheme), nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if agho == 0 || dual <= agho {
+        t.Errorf("proof sizes %d for AGHO and %d for Dual AGHO", agho, dual)
+    }
+}
+
+/*
+ * An ecert signed for an epoch is only accepted with that epoch as the
+ * current epoch
+ */
+func TestEpochProof(t *testing.T) {
+    f := newProofFixture()
+    VK, SK := SKeyGen(f.sharedParams, nil)
+    f.vars.VK = VK
+    f.consts.VK = VK
+    f.consts.Egz = f.pairing.NewGT().Pair(f.pairing.NewG1().SetBytes(f.sharedParams.G1),
+        f.pairing.NewG2().SetBytes(VK.Z)).Bytes()
+    epoch := NewEpoch(f.sharedParams, 3)
+    f.vars.E = SSign(f.sharedParams, SK, f.vars.P, f.vars.PKc, epoch, nil)
+    pi := PSetup(f.sharedParams, f.vars, nil)
+
+    f.consts.Epoch = epoch.E
+    if !PProve(f.sharedParams, pi, f.consts) {
+        t.Error("current epoch rejected")
+    }
+    f.consts.Epoch = NewEpoch(f.sharedParams, 4).E
+    if PProve(f.sharedParams, pi, f.consts) {
+        t.Error("expired epoch accepted")
+    }
+}
+
+func BenchmarkCreateCommonReferenceString(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    alpha := pairing.NewZr().Rand()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        CreateCommonReferenceString(sharedParams, alpha, nil)
+    }
+}
+
+func BenchmarkCreateCommitment(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    sigma := CreateCommonReferenceString(sharedParams, pairing.NewZr().Rand(), nil)
+    for _, tc := range commitmentCases(pairing, sigma) {
+        chi := []*pbc.Element{tc.newVariable().Rand()}
+        b.Run(tc.name, func(b *testing.B) {
+            for i := 0; i < b.N; i++ {
+                tc.commit(pairing, chi, sigma, nil)
+            }
+        })
+    }
+}
+
+func BenchmarkFMap(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    B1 := &BPair{pairing.NewG1().Rand().Bytes(), pairing.NewG1().Rand().Bytes()}
+    B2 := &BPair{pairing.NewG2().Rand().Bytes(), pairing.NewG2().Rand().Bytes()}
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        FMap(pairing, B1, B2)
+    }
+}
+
+func BenchmarkProveEquation(b *testing.B) {
+    f := newProofFixture()
+    for _, tc := range f.equationCases() {
+        b.Run(tc.name, func(b *testing.B) {
+            for i := 0; i < b.N; i++ {
+                tc.prove(nil)
+            }
+        })
+    }
+}
+
+func BenchmarkVerifyEquation(b *testing.B) {
+    f := newProofFixture()
+    for _, tc := range f.equationCases() {
+        proof := tc.prove(nil)
+        b.Run(tc.name, func(b *testing.B) {
+            for i := 0; i < b.N; i++ {
+                tc.verify(proof)
+            }
+        })
+    }
+}
+
+func BenchmarkPSetup(b *testing.B) {
+    f := newProofFixture()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        PSetup(f.sharedParams, f.vars, nil)
+    }
+}
+
+func BenchmarkPProve(b *testing.B) {
+    f := newProofFixture()
+    pi := PSetup(f.sharedParams, f.vars, nil)
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        PProve(f.sharedParams, pi, f.consts)
+    }
+}
@@ -0,0 +1,149 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Randomness sources. Every scheme function that needs randomness takes
+ * an io.Reader as its last argument and draws random scalars and group
+ * elements from it, or from PBC's own generator when the reader is nil.
+ * Any reader will do, e.g. crypto/rand.Reader, a recorded stream for test
+ * vectors or a wrapper that checks the quality of the stream. A
+ * PRF reader gives deterministic randomness: GenECert reads its
+ * randomness from a PRF of the secret issuer seed and the transaction ID,
+ * so all endorsers of a proposal compute the same pseudonym and ecert,
+ * while nobody without the seed can predict them. The seed is in the
+ * issuer collection, see issuer_secrets.go.
+ */
+
+package ocert
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/binary"
+    "fmt"
+    "io"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * A random element of Zr from rand, or from PBC if rand is nil. 64 bytes
+ * are reduced modulo the group order, so the bias is negligible.
+ */
+func randZr(pairing *pbc.Pairing, rand io.Reader) *pbc.Element {
+    if rand == nil {
+        return pairing.NewZr().Rand()
+    }
+    return pairing.NewZr().SetFromHash(randBytes(rand))
+}
+
+/*
+ * A random element of G1 or G2 from rand, or from PBC if rand is nil
+ */
+func randG1(pairing *pbc.Pairing, rand io.Reader) *pbc.Element {
+    if rand == nil {
+        return pairing.NewG1().Rand()
+    }
+    return pairing.NewG1().SetFromHash(randBytes(rand))
+}
+
+func randG2(pairing *pbc.Pairing, rand io.Reader) *pbc.Element {
+    if rand == nil {
+        return pairing.NewG2().Rand()
+    }
+    return pairing.NewG2().SetFromHash(randBytes(rand))
+}
+
+func randBytes(rand io.Reader) []byte {
+    buf := make([]byte, 64)
+    _, err := io.ReadFull(rand, buf)
+    if err != nil {
+        panic(fmt.Sprintf("Randomness source failed: %s", err))
+    }
+    return buf
+}
+
+/*
+ * HMAC-SHA256 in counter mode, keyed by seed and bound to info
+ */
+type prfReader struct {
+    mac     []byte
+    seed    []byte
+    info    []byte
+    counter uint64
+}
+
+/*
+ * A deterministic randomness source: the same seed and info always give
+ * the same stream
+ */
+func NewPRFReader(seed []byte, info ...[]byte) io.Reader {
+    reader := new(prfReader)
+    reader.seed = seed
+    // Length prefixed, so different info lists never collide
+    for _, part := range info {
+        length := make([]byte, 8)
+        binary.BigEndian.PutUint64(length, uint64(len(part)))
+        reader.info = append(reader.info, length...)
+        reader.info = append(reader.info, part...)
+    }
+    return reader
+}
+
+func (reader *prfReader) Read(p []byte) (int, error) {
+    n := 0
+    for n < len(p) {
+        if len(reader.mac) == 0 {
+            h := hmac.New(sha256.New, reader.seed)
+            counter := make([]byte, 8)
+            binary.BigEndian.PutUint64(counter, reader.counter)
+            h.Write(counter)
+            h.Write(reader.info)
+            reader.mac = h.Sum(nil)
+            reader.counter++
+        }
+        copied := copy(p[n:], reader.mac)
+        reader.mac = reader.mac[copied:]
+        n += copied
+    }
+    return n, nil
+}
+
+/*
+ * The randomness of a transaction that issues an ecert. It is bound to
+ * the encoded request, so a replayed transaction ID with another request
+ * gets fresh randomness, and each use in the transaction reads its own
+ * stream, named by label.
+ */
+func txRandomness(stub Wrapper, function string, request []byte, label string) (io.Reader, error) {
+    seed, err := getIssuerSeed(stub)
+    if err != nil {
+        return nil, err
+    }
+    hash := sha256.Sum256(request)
+    return NewPRFReader(seed, []byte(function), []byte(stub.GetTxID()), hash[:], []byte(label)), nil
+}
+
+/*
+ * The randomness of a transaction endorsed by the auditor, as
+ * txRandomness but keyed by the auditor secret key, since peers of the
+ * auditor org do not hold the issuer seed
+ */
+func auditorTxRandomness(stub Wrapper, SKa *AuditorSecretKey, function string, request []byte, label string) io.Reader {
+    hash := sha256.Sum256(request)
+    return NewPRFReader(SKa.SK, []byte("auditor"), []byte(function), []byte(stub.GetTxID()), hash[:], []byte(label))
+}
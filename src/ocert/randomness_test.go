@@ -0,0 +1,216 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "bytes"
+    "io"
+    "reflect"
+    "testing"
+    "github.com/Nik-U/pbc"
+)
+
+func readPRF(reader io.Reader, n int) []byte {
+    buf := make([]byte, n)
+    io.ReadFull(reader, buf)
+    return buf
+}
+
+/*
+ * The PRF reader gives the same stream for the same seed and info
+ */
+func TestPRFReader(t *testing.T) {
+    seed := []byte("seed")
+    a := readPRF(NewPRFReader(seed, []byte("a"), []byte("b")), 100)
+    if !bytes.Equal(a, readPRF(NewPRFReader(seed, []byte("a"), []byte("b")), 100)) {
+        t.Error("same seed and info give different streams")
+    }
+    // Short reads continue the same stream
+    reader := NewPRFReader(seed, []byte("a"), []byte("b"))
+    if !bytes.Equal(a, append(readPRF(reader, 7), readPRF(reader, 93)...)) {
+        t.Error("split reads differ")
+    }
+
+    others := []struct {
+        name string
+        reader io.Reader
+    }{
+        {"InfoBoundaries", NewPRFReader(seed, []byte("ab"))},
+        {"Seed", NewPRFReader([]byte("other"), []byte("a"), []byte("b"))},
+    }
+    for _, tc := range others {
+        if bytes.Equal(a, readPRF(tc.reader, 100)) {
+            t.Errorf("%s: different inputs give the same stream", tc.name)
+        }
+    }
+}
+
+/*
+ * Endorsing the same GenECert proposal twice gives the same reply, and a
+ * different transaction, or another request under the same transaction
+ * ID, gives a fresh pseudonym
+ */
+func TestGenECertDeterministic(t *testing.T) {
+    stub := NewMemoryStub()
+    if _, err := Setup(stub, [][]byte{[]byte(""), nil}); err != nil {
+        t.Fatal(err)
+    }
+
+    stub.Creator = newTestCreator("Org1MSP", "alice")
+    request := new(GenECertRequest)
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    request.PKc = pairing.NewG2().Rand().Bytes()
+    requestBytes, err := request.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Transient = TransientRequest(requestBytes)
+
+    stub.NextTx()
+    first, err := GenECert(stub, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    second, err := GenECert(stub, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.Equal(first, second) {
+        t.Error("same transaction gives different replies")
+    }
+
+    replayed := new(GenECertRequest)
+    replayed.PKc = pairing.NewG2().Rand().Bytes()
+    replayedBytes, err := replayed.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Transient = TransientRequest(replayedBytes)
+    replayedReply, err := GenECert(stub, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    firstReply, secondReply := new(GenECertReply), new(GenECertReply)
+    if err := firstReply.SetBytes(first); err != nil {
+        t.Fatal(err)
+    }
+    if err := secondReply.SetBytes(replayedReply); err != nil {
+        t.Fatal(err)
+    }
+    if bytes.Equal(firstReply.P, secondReply.P) || reflect.DeepEqual(firstReply.EncProof, secondReply.EncProof) {
+        t.Error("another request under the same transaction ID reuses the randomness")
+    }
+    stub.Transient = TransientRequest(requestBytes)
+
+    stub.NextTx()
+    third, err := GenECert(stub, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if bytes.Equal(first, third) {
+        t.Error("different transactions give the same reply")
+    }
+
+    // The reply must still verify
+    reply := new(GenECertReply)
+    if err := reply.SetBytes(third); err != nil {
+        t.Fatal(err)
+    }
+    P := new(Pseudonym)
+    if err := P.SetBytes(reply.P); err != nil {
+        t.Fatal(err)
+    }
+    PKa := new(AuditorPublicKey)
+    PKaBytes, _ := stub.GetState("auditor_pk")
+    if err := PKa.SetBytes(PKaBytes); err != nil {
+        t.Fatal(err)
+    }
+    IDc := NewClientID(sharedParams, reply.EnrollmentID)
+    if !VerifyEncryption(sharedParams, PKa, P, IDc, reply.EncProof) {
+        t.Error("cannot verify the encryption proof of the reply")
+    }
+}
+
+/*
+ * The scheme functions give the same output for the same randomness
+ * source, and that output is still valid
+ */
+func TestInjectedRandomness(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    id := new(ClientID)
+    id.ID = pairing.NewG1().Rand().Bytes()
+    PKc := new(ClientPublicKey)
+    PKc.PK = pairing.NewG2().Rand().Bytes()
+    epoch := NewEpoch(sharedParams, 1)
+
+    type outputs struct {
+        PKa *AuditorPublicKey
+        SKa *AuditorSecretKey
+        P *Pseudonym
+        PPrime *Pseudonym
+        RPrime []byte
+        VK *SVerificationKey
+        SK *SSigningKey
+        Ecert *Ecert
+        Sigma *CommonReferenceString
+        R [][]byte
+    }
+    run := func(rand io.Reader) *outputs {
+        out := new(outputs)
+        out.PKa, out.SKa = EKeyGen(sharedParams, rand)
+        out.P = EEnc(sharedParams, out.PKa, id, rand)
+        out.PPrime, out.RPrime = ERerand(sharedParams, out.PKa, out.P, rand)
+        out.VK, out.SK = SKeyGen(sharedParams, rand)
+        out.Ecert = SSign(sharedParams, out.SK, out.PPrime, PKc, epoch, rand)
+        out.Sigma = CreateCommonReferenceString(sharedParams, randZr(pairing, rand), rand)
+        R := NewRMatrix(pairing, 2, 2, rand)
+        for _, row := range R.mat {
+            for _, el := range row {
+                out.R = append(out.R, el.Bytes())
+            }
+        }
+        return out
+    }
+
+    first := run(NewPRFReader([]byte("seed")))
+    second := run(NewPRFReader([]byte("seed")))
+    if !reflect.DeepEqual(first, second) {
+        t.Error("same randomness gives different outputs")
+    }
+    other := run(NewPRFReader([]byte("other")))
+    if reflect.DeepEqual(first.PKa, other.PKa) || reflect.DeepEqual(first.Ecert, other.Ecert) {
+        t.Error("different randomness gives the same outputs")
+    }
+
+    if !reflect.DeepEqual(EDec(sharedParams, first.SKa, first.PPrime), id) {
+        t.Error("pseudonym does not decrypt")
+    }
+    if !SVerify(sharedParams, first.VK, first.PPrime, PKc, epoch, first.Ecert) {
+        t.Error("cannot verify the ecert")
+    }
+}
+
+func BenchmarkPRFReader(b *testing.B) {
+    reader := NewPRFReader([]byte("seed"), []byte("bench"))
+    buf := make([]byte, 1024)
+    b.SetBytes(int64(len(buf)))
+    for i := 0; i < b.N; i++ {
+        io.ReadFull(reader, buf)
+    }
+}
@@ -23,13 +23,16 @@
 package ocert
 
 import (
+    "crypto/sha256"
+    "fmt"
+    "io"
     "github.com/Nik-U/pbc"
 )
 
 /*
  * Generate the pair of public key and secret key used by auditor.
  */
-func EKeyGen(sharedParams *SharedParams) (*AuditorPublicKey, *AuditorSecretKey) {
+func EKeyGen(sharedParams *SharedParams, rand io.Reader) (*AuditorPublicKey, *AuditorSecretKey) {
     PKa := new(AuditorPublicKey)
     SKa := new(AuditorSecretKey)
 
@@ -38,7 +41,7 @@ func EKeyGen(sharedParams *SharedParams) (*AuditorPublicKey, *AuditorSecretKey)
     g1 := pairing.NewG1().SetBytes(sharedParams.G1)
 
 
-    xa :=pairing.NewZr().Rand()
+    xa := randZr(pairing, rand)
     SKa.SK=xa.Bytes()
 
     //produce the public key
@@ -53,12 +56,12 @@ func EKeyGen(sharedParams *SharedParams) (*AuditorPublicKey, *AuditorSecretKey)
  * result is the pseudonym of a client, where pseudonym of a client
  * has form (C, D), where both C and D are in G1
  */
-func EEnc(sharedParams *SharedParams, PKa *AuditorPublicKey, id *ClientID) *Pseudonym {
+func EEnc(sharedParams *SharedParams, PKa *AuditorPublicKey, id *ClientID, rand io.Reader) *Pseudonym {
     P := new(Pseudonym)
 
     pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
     g1 := pairing.NewG1().SetBytes(sharedParams.G1)
-    r := pairing.NewZr().Rand()
+    r := randZr(pairing, rand)
 
     C := pairing.NewG1().MulZn(g1,r)
 
@@ -77,6 +80,43 @@ func EEnc(sharedParams *SharedParams, PKa *AuditorPublicKey, id *ClientID) *Pseu
     return P
 }
 
+/*
+ * Encrypt the client id like EEnc, and prove that the pseudonym (C, D)
+ * encrypts id under PKa: C = r * g1 and D - id = r * PKa
+ */
+func EEncWithProof(sharedParams *SharedParams, PKa *AuditorPublicKey, id *ClientID, rand io.Reader) (*Pseudonym, *DLEQProof) {
+    P := new(Pseudonym)
+
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    r := randZr(pairing, rand)
+
+    C := pairing.NewG1().MulZn(g1, r)
+    PK := pairing.NewG1().SetBytes(PKa.PK)
+    rPK := pairing.NewG1().MulZn(PK, r)
+    D := pairing.NewG1().Add(rPK, pairing.NewG1().SetBytes(id.ID))
+
+    P.C = C.Bytes()
+    P.D = D.Bytes()
+    return P, dleqProve(pairing, g1, C, PK, rPK, r, rand)
+}
+
+/*
+ * Verify that the pseudonym P encrypts id under PKa, called by the client
+ * before it accepts an ecert
+ */
+func VerifyEncryption(sharedParams *SharedParams, PKa *AuditorPublicKey, P *Pseudonym, id *ClientID, proof *DLEQProof) bool {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+
+    PK := pairing.NewG1().SetBytes(PKa.PK)
+    C := pairing.NewG1().SetBytes(P.C)
+    D := pairing.NewG1().SetBytes(P.D)
+    rPK := pairing.NewG1().Sub(D, pairing.NewG1().SetBytes(id.ID))
+
+    return dleqVerify(pairing, g1, C, PK, rPK, proof)
+}
+
 /*
  * Decrypt the client real identiy based on the pseudonym of a client
  */
@@ -93,12 +133,115 @@ func EDec(sharedParams *SharedParams, SKa *AuditorSecretKey, P *Pseudonym) *Clie
     return id
 }
 
+/*
+ * Decrypt the client real identity like EDec, and prove that the auditor
+ * decrypted honestly: D - ID = x * C for the same x as PKa = x * g1
+ */
+func EDecWithProof(sharedParams *SharedParams, SKa *AuditorSecretKey, P *Pseudonym, rand io.Reader) (*ClientID, *DLEQProof) {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+
+    C := pairing.NewG1().SetBytes(P.C)
+    D := pairing.NewG1().SetBytes(P.D)
+    x := pairing.NewZr().SetBytes(SKa.SK)
+
+    PK := pairing.NewG1().MulZn(g1, x)
+    xC := pairing.NewG1().MulZn(C, x)
+
+    id := new(ClientID)
+    id.ID = pairing.NewG1().Sub(D, xC).Bytes()
+    return id, dleqProve(pairing, g1, PK, C, xC, x, rand)
+}
+
+/*
+ * Verify with public data only that id is the decryption of P under the
+ * auditor's key
+ */
+func VerifyDecryption(sharedParams *SharedParams, PKa *AuditorPublicKey, P *Pseudonym, id *ClientID, proof *DLEQProof) bool {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+
+    PK := pairing.NewG1().SetBytes(PKa.PK)
+    C := pairing.NewG1().SetBytes(P.C)
+    D := pairing.NewG1().SetBytes(P.D)
+    xC := pairing.NewG1().Sub(D, pairing.NewG1().SetBytes(id.ID))
+
+    return dleqVerify(pairing, g1, PK, C, xC, proof)
+}
+
+/*
+ * Decide whether P1 and P2 encrypt the same client id without decrypting
+ * either of them. The quotient of P1 and P2 encrypts 0 exactly if the ids
+ * are equal; it is blinded with a random z before it is decrypted, so the
+ * result reveals nothing but the answer. The proof can be checked by
+ * anyone with VerifyEqualityTest.
+ */
+func EEqualityTest(sharedParams *SharedParams, SKa *AuditorSecretKey, P1 *Pseudonym, P2 *Pseudonym, rand io.Reader) (bool, *EqualityProof) {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    x := pairing.NewZr().SetBytes(SKa.SK)
+
+    C := pairing.NewG1().Sub(pairing.NewG1().SetBytes(P1.C), pairing.NewG1().SetBytes(P2.C))
+    D := pairing.NewG1().Sub(pairing.NewG1().SetBytes(P1.D), pairing.NewG1().SetBytes(P2.D))
+    if C.Is0() {
+        return D.Is0(), nil
+    }
+
+    z := randZr(pairing, rand)
+    for z.Is0() {
+        z = randZr(pairing, rand)
+    }
+    zC := pairing.NewG1().MulZn(C, z)
+    zD := pairing.NewG1().MulZn(D, z)
+    xzC := pairing.NewG1().MulZn(zC, x)
+
+    proof := new(EqualityProof)
+    proof.C = zC.Bytes()
+    proof.D = zD.Bytes()
+    proof.XC = xzC.Bytes()
+    proof.Blinding = dleqProve(pairing, C, zC, D, zD, z, rand)
+    proof.Decryption = dleqProve(pairing, g1, pairing.NewG1().MulZn(g1, x), zC, xzC, x, rand)
+    return xzC.Equals(zD), proof
+}
+
+/*
+ * Verify the result of EEqualityTest with public data only
+ */
+func VerifyEqualityTest(sharedParams *SharedParams, PKa *AuditorPublicKey, P1 *Pseudonym, P2 *Pseudonym, equal bool, proof *EqualityProof) bool {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+
+    C := pairing.NewG1().Sub(pairing.NewG1().SetBytes(P1.C), pairing.NewG1().SetBytes(P2.C))
+    D := pairing.NewG1().Sub(pairing.NewG1().SetBytes(P1.D), pairing.NewG1().SetBytes(P2.D))
+    if C.Is0() {
+        return equal == D.Is0()
+    }
+    if proof == nil {
+        return false
+    }
+
+    // z must not be 0, otherwise every pair would test equal
+    zC := pairing.NewG1().SetBytes(proof.C)
+    zD := pairing.NewG1().SetBytes(proof.D)
+    xzC := pairing.NewG1().SetBytes(proof.XC)
+    if zC.Is0() {
+        return false
+    }
+    if !dleqVerify(pairing, C, zC, D, zD, proof.Blinding) {
+        return false
+    }
+    if !dleqVerify(pairing, g1, pairing.NewG1().SetBytes(PKa.PK), zC, xzC, proof.Decryption) {
+        return false
+    }
+    return equal == xzC.Equals(zD)
+}
+
 /*
  * Rerandomize the client's pseudonym. Given a pseudonym P = (C, D)
  * of a client, this scheme can rerandomize it to a new pseudonym
  * P' = (C', D'), where P' is also in G1 * G1.
  */
-func ERerand(sharedParams *SharedParams, PKa *AuditorPublicKey, P *Pseudonym) (*Pseudonym, []byte) {
+func ERerand(sharedParams *SharedParams, PKa *AuditorPublicKey, P *Pseudonym, rand io.Reader) (*Pseudonym, []byte) {
     // TODO rerandomize P
     pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
     g1 := pairing.NewG1().SetBytes(sharedParams.G1)
@@ -106,7 +249,7 @@ func ERerand(sharedParams *SharedParams, PKa *AuditorPublicKey, P *Pseudonym) (*
     PPrime := new(Pseudonym)
 
     //Generate rprime
-    rprime := pairing.NewZr().Rand()
+    rprime := randZr(pairing, rand)
 
     //Getting C & D from the Pseudonym P that has been passed
     C := pairing.NewG1().SetBytes(P.C)
@@ -149,3 +292,172 @@ func ERerandVerify(sharedParams *SharedParams, SKa *AuditorSecretKey, P *Pseudon
 
     return xrG.Equals(pairing.NewG1().Sub(Dprime, D))
 }
+
+/*
+ * Prove that P' was rerandomized from P with rprime, that is
+ * (C' - C, D' - D) = rprime * (g1, PKa). A client that kept rprime from
+ * ERerand can prove this at any time to link two of its pseudonyms.
+ */
+func ERerandProve(sharedParams *SharedParams, PKa *AuditorPublicKey, P *Pseudonym, PPrime *Pseudonym, rprime []byte, rand io.Reader) *DLEQProof {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    PK := pairing.NewG1().SetBytes(PKa.PK)
+    r := pairing.NewZr().SetBytes(rprime)
+
+    rG := pairing.NewG1().Sub(pairing.NewG1().SetBytes(PPrime.C), pairing.NewG1().SetBytes(P.C))
+    rPK := pairing.NewG1().Sub(pairing.NewG1().SetBytes(PPrime.D), pairing.NewG1().SetBytes(P.D))
+    return dleqProve(pairing, g1, rG, PK, rPK, r, rand)
+}
+
+/*
+ * Rerandomize P like ERerand, together with a proof that anyone can check
+ * with ERerandVerifyPublic
+ */
+func ERerandWithProof(sharedParams *SharedParams, PKa *AuditorPublicKey, P *Pseudonym, rand io.Reader) (*Pseudonym, []byte, *DLEQProof) {
+    PPrime, rprime := ERerand(sharedParams, PKa, P, rand)
+    return PPrime, rprime, ERerandProve(sharedParams, PKa, P, PPrime, rprime, rand)
+}
+
+/*
+ * Given two pseudonyms P and P', validate whether P' is rerandomized
+ * from P with public data only
+ */
+func ERerandVerifyPublic(sharedParams *SharedParams, PKa *AuditorPublicKey, P *Pseudonym, PPrime *Pseudonym, proof *DLEQProof) bool {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    PK := pairing.NewG1().SetBytes(PKa.PK)
+
+    rG := pairing.NewG1().Sub(pairing.NewG1().SetBytes(PPrime.C), pairing.NewG1().SetBytes(P.C))
+    rPK := pairing.NewG1().Sub(pairing.NewG1().SetBytes(PPrime.D), pairing.NewG1().SetBytes(P.D))
+    return dleqVerify(pairing, g1, rG, PK, rPK, proof)
+}
+
+/*
+ * The token that re-encrypts pseudonyms under the auditor key SKaOld of
+ * version from into pseudonyms under SKaNew of version to. Computing it
+ * needs both keys, so the old and new key holders have to cooperate.
+ */
+func EReKeyToken(sharedParams *SharedParams, SKaOld *AuditorSecretKey, from int, SKaNew *AuditorSecretKey, to int) *ReKeyToken {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    xOld := pairing.NewZr().SetBytes(SKaOld.SK)
+    xNew := pairing.NewZr().SetBytes(SKaNew.SK)
+
+    token := new(ReKeyToken)
+    token.From = from
+    token.To = to
+    token.Delta = pairing.NewZr().Sub(xNew, xOld).Bytes()
+    return token
+}
+
+/*
+ * Chain the tokens from version a to b and from b to c into the token
+ * from a to c
+ */
+func ECombineReKeyTokens(sharedParams *SharedParams, first *ReKeyToken, second *ReKeyToken) (*ReKeyToken, error) {
+    if first.To != second.From {
+        return nil, fmt.Errorf("Cannot chain re-encryption tokens %d->%d and %d->%d",
+            first.From, first.To, second.From, second.To)
+    }
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    token := new(ReKeyToken)
+    token.From = first.From
+    token.To = second.To
+    token.Delta = pairing.NewZr().Add(pairing.NewZr().SetBytes(first.Delta), pairing.NewZr().SetBytes(second.Delta)).Bytes()
+    return token, nil
+}
+
+/*
+ * Check that token re-encrypts from PKaOld to PKaNew, that is
+ * PKaNew = PKaOld + delta * g1
+ */
+func EVerifyReKeyToken(sharedParams *SharedParams, PKaOld *AuditorPublicKey, PKaNew *AuditorPublicKey, token *ReKeyToken) bool {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    delta := pairing.NewZr().SetBytes(token.Delta)
+    PK := pairing.NewG1().Add(pairing.NewG1().SetBytes(PKaOld.PK), pairing.NewG1().MulZn(g1, delta))
+    return PK.Equals(pairing.NewG1().SetBytes(PKaNew.PK))
+}
+
+func reEncryptionChallenge(pairing *pbc.Pairing, elements ...*pbc.Element) *pbc.Element {
+    h := sha256.New()
+    h.Write([]byte("ocert-reencryption"))
+    for _, e := range elements {
+        h.Write(e.Bytes())
+    }
+    return pairing.NewZr().SetFromHash(h.Sum(nil))
+}
+
+/*
+ * Re-encrypt the pseudonym P = (C, D) under the old auditor key into
+ * P' = (C + s * g1, D + delta * C + s * PKaNew) under the new key,
+ * without decrypting it. P' is rerandomized with a fresh s, so it cannot
+ * be linked to P without the proof. The proof shows with public data
+ * only that P' encrypts the same id, see VerifyReEncryption.
+ */
+func EReEncrypt(sharedParams *SharedParams, token *ReKeyToken, PKaNew *AuditorPublicKey, P *Pseudonym, rand io.Reader) (*Pseudonym, *ReEncryptionProof) {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    PK := pairing.NewG1().SetBytes(PKaNew.PK)
+    delta := pairing.NewZr().SetBytes(token.Delta)
+    s := randZr(pairing, rand)
+
+    C := pairing.NewG1().SetBytes(P.C)
+    D := pairing.NewG1().SetBytes(P.D)
+    deltaC := pairing.NewG1().MulZn(C, delta)
+    sG := pairing.NewG1().MulZn(g1, s)
+    sPK := pairing.NewG1().MulZn(PK, s)
+
+    PPrime := new(Pseudonym)
+    PPrime.C = pairing.NewG1().Add(C, sG).Bytes()
+    PPrime.D = pairing.NewG1().Add(pairing.NewG1().Add(D, deltaC), sPK).Bytes()
+
+    // Schnorr proof of delta and s:
+    // delta * g1 = PKaNew - PKaOld, s * g1 = C' - C and
+    // delta * C + s * PKaNew = D' - D
+    w1 := randZr(pairing, rand)
+    w2 := randZr(pairing, rand)
+    T1 := pairing.NewG1().MulZn(g1, w1)
+    T2 := pairing.NewG1().MulZn(g1, w2)
+    T3 := pairing.NewG1().Add(pairing.NewG1().MulZn(C, w1), pairing.NewG1().MulZn(PK, w2))
+    deltaD := pairing.NewG1().Add(deltaC, sPK)
+
+    c := reEncryptionChallenge(pairing, g1, C, PK, pairing.NewG1().MulZn(g1, delta), sG, deltaD, T1, T2, T3)
+    proof := new(ReEncryptionProof)
+    proof.Challenge = c.Bytes()
+    proof.KeyResponse = pairing.NewZr().Add(w1, pairing.NewZr().Mul(c, delta)).Bytes()
+    proof.RerandResponse = pairing.NewZr().Add(w2, pairing.NewZr().Mul(c, s)).Bytes()
+    return PPrime, proof
+}
+
+/*
+ * Verify that P' is the re-encryption of P from PKaOld to PKaNew:
+ * C' - C = s * g1 and D' - D = delta * C + s * PKaNew where
+ * PKaNew - PKaOld = delta * g1
+ */
+func VerifyReEncryption(sharedParams *SharedParams, PKaOld *AuditorPublicKey, PKaNew *AuditorPublicKey, P *Pseudonym, PPrime *Pseudonym, proof *ReEncryptionProof) bool {
+    if proof == nil {
+        return false
+    }
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    PK := pairing.NewG1().SetBytes(PKaNew.PK)
+    C := pairing.NewG1().SetBytes(P.C)
+
+    deltaG := pairing.NewG1().Sub(PK, pairing.NewG1().SetBytes(PKaOld.PK))
+    sG := pairing.NewG1().Sub(pairing.NewG1().SetBytes(PPrime.C), C)
+    deltaD := pairing.NewG1().Sub(pairing.NewG1().SetBytes(PPrime.D), pairing.NewG1().SetBytes(P.D))
+
+    c := pairing.NewZr().SetBytes(proof.Challenge)
+    z1 := pairing.NewZr().SetBytes(proof.KeyResponse)
+    z2 := pairing.NewZr().SetBytes(proof.RerandResponse)
+
+    // T1 = z1 * g1 - c * deltaG, T2 = z2 * g1 - c * sG,
+    // T3 = z1 * C + z2 * PKaNew - c * deltaD
+    T1 := pairing.NewG1().Sub(pairing.NewG1().MulZn(g1, z1), pairing.NewG1().MulZn(deltaG, c))
+    T2 := pairing.NewG1().Sub(pairing.NewG1().MulZn(g1, z2), pairing.NewG1().MulZn(sG, c))
+    T3 := pairing.NewG1().Add(pairing.NewG1().MulZn(C, z1), pairing.NewG1().MulZn(PK, z2))
+    T3.Sub(T3, pairing.NewG1().MulZn(deltaD, c))
+
+    return c.Equals(reEncryptionChallenge(pairing, g1, C, PK, deltaG, sG, deltaD, T1, T2, T3))
+}
@@ -0,0 +1,393 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "bytes"
+    "reflect"
+    "testing"
+    "github.com/Nik-U/pbc"
+)
+
+func newTestClientID(pairing *pbc.Pairing) *ClientID {
+    id := new(ClientID)
+    id.ID = pairing.NewG1().Rand().Bytes()
+    return id
+}
+
+func TestEKeyGen(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    PK, SK := EKeyGen(sharedParams, nil)
+
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    PKa := pairing.NewG1().MulZn(g1, pairing.NewZr().SetBytes(SK.SK))
+    if !PKa.Equals(pairing.NewG1().SetBytes(PK.PK)) {
+        t.Error("PKa != SKa * G")
+    }
+}
+
+func TestEEncDec(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    PK, SK := EKeyGen(sharedParams, nil)
+
+    id := newTestClientID(pairing)
+    P := EEnc(sharedParams, PK, id, nil)
+    if !reflect.DeepEqual(id, EDec(sharedParams, SK, P)) {
+        t.Error("pseudonym does not decrypt to the client id")
+    }
+    Pprime, _ := ERerand(sharedParams, PK, P, nil)
+    if !reflect.DeepEqual(id, EDec(sharedParams, SK, Pprime)) {
+        t.Error("rerandomized pseudonym does not decrypt to the client id")
+    }
+}
+
+func TestERerandVerify(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    PK, SK := EKeyGen(sharedParams, nil)
+
+    P := EEnc(sharedParams, PK, newTestClientID(pairing), nil)
+    Pprime, _ := ERerand(sharedParams, PK, P, nil)
+    if !ERerandVerify(sharedParams, SK, P, Pprime) {
+        t.Error("rerandomization rejected")
+    }
+}
+
+/*
+ * Verify a rerandomization without the auditor's secret key, and link
+ * two pseudonyms of a client after the fact
+ */
+func TestERerandVerifyPublic(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    PK, _ := EKeyGen(sharedParams, nil)
+    P := EEnc(sharedParams, PK, newTestClientID(pairing), nil)
+
+    Pprime, rprime, proof := ERerandWithProof(sharedParams, PK, P, nil)
+    if !ERerandVerifyPublic(sharedParams, PK, P, Pprime, proof) {
+        t.Error("valid rerandomization rejected")
+    }
+
+    // Link P to a second rerandomization later on
+    Pprime2, rprime2 := ERerand(sharedParams, PK, P, nil)
+    proof2 := ERerandProve(sharedParams, PK, P, Pprime2, rprime2, nil)
+    if !ERerandVerifyPublic(sharedParams, PK, P, Pprime2, proof2) {
+        t.Error("link rejected")
+    }
+
+    // A pseudonym of another id is not a rerandomization of P
+    other := EEnc(sharedParams, PK, newTestClientID(pairing), nil)
+    if ERerandVerifyPublic(sharedParams, PK, P, other, ERerandProve(sharedParams, PK, P, other, rprime, nil)) {
+        t.Error("pseudonym of another id accepted")
+    }
+    if ERerandVerifyPublic(sharedParams, PK, P, Pprime2, proof) {
+        t.Error("proof of another rerandomization accepted")
+    }
+}
+
+/*
+ * Check the proof that a pseudonym encrypts a client id, and that an
+ * issuer cannot frame the client with the pseudonym of another id
+ */
+func TestEncryptionProof(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    PK, SK := EKeyGen(sharedParams, nil)
+
+    id := newTestClientID(pairing)
+    P, proof := EEncWithProof(sharedParams, PK, id, nil)
+    if !reflect.DeepEqual(id, EDec(sharedParams, SK, P)) {
+        t.Error("pseudonym does not decrypt to the client id")
+    }
+    if !VerifyEncryption(sharedParams, PK, P, id, proof) {
+        t.Error("valid encryption rejected")
+    }
+
+    framed, framedProof := EEncWithProof(sharedParams, PK, newTestClientID(pairing), nil)
+    if VerifyEncryption(sharedParams, PK, framed, id, framedProof) {
+        t.Error("pseudonym of another id accepted")
+    }
+    otherPK, _ := EKeyGen(sharedParams, nil)
+    if VerifyEncryption(sharedParams, otherPK, P, id, proof) {
+        t.Error("wrong auditor accepted")
+    }
+}
+
+/*
+ * Check the proof of a decryption, and that it does not verify for
+ * another id or another auditor
+ */
+func TestDecryptionProof(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    PK, SK := EKeyGen(sharedParams, nil)
+
+    id := newTestClientID(pairing)
+    P := EEnc(sharedParams, PK, id, nil)
+    decryptID, proof := EDecWithProof(sharedParams, SK, P, nil)
+    if !reflect.DeepEqual(id, decryptID) {
+        t.Error("pseudonym does not decrypt to the client id")
+    }
+    if !VerifyDecryption(sharedParams, PK, P, decryptID, proof) {
+        t.Error("valid decryption rejected")
+    }
+    if VerifyDecryption(sharedParams, PK, P, newTestClientID(pairing), proof) {
+        t.Error("wrong id accepted")
+    }
+    otherPK, _ := EKeyGen(sharedParams, nil)
+    if VerifyDecryption(sharedParams, otherPK, P, decryptID, proof) {
+        t.Error("wrong auditor accepted")
+    }
+}
+
+/*
+ * Test a pseudonym against its rerandomization and against the pseudonym
+ * of another id, and check that a wrong answer does not verify
+ */
+func TestEEqualityTest(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    PK, SK := EKeyGen(sharedParams, nil)
+
+    P1 := EEnc(sharedParams, PK, newTestClientID(pairing), nil)
+    P2, _ := ERerand(sharedParams, PK, P1, nil)
+    P3 := EEnc(sharedParams, PK, newTestClientID(pairing), nil)
+
+    cases := []struct {
+        name string
+        P *Pseudonym
+        Q *Pseudonym
+        equal bool
+    }{
+        {"SameID", P1, P2, true},
+        {"OtherID", P1, P3, false},
+    }
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            equal, proof := EEqualityTest(sharedParams, SK, tc.P, tc.Q, nil)
+            if equal != tc.equal {
+                t.Fatalf("got %v, want %v", equal, tc.equal)
+            }
+            if !VerifyEqualityTest(sharedParams, PK, tc.P, tc.Q, equal, proof) {
+                t.Error("valid answer rejected")
+            }
+            if VerifyEqualityTest(sharedParams, PK, tc.P, tc.Q, !equal, proof) {
+                t.Error("wrong answer accepted")
+            }
+            // The proof of one pair does not verify for another pair
+            if VerifyEqualityTest(sharedParams, PK, P2, P3, equal, proof) {
+                t.Error("proof accepted for another pair")
+            }
+        })
+    }
+}
+
+/*
+ * Re-encrypt a pseudonym across two key rotations and check that the id
+ * is preserved, the pseudonym is rerandomized and the proofs verify
+ */
+func TestEReEncrypt(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    PK0, SK0 := EKeyGen(sharedParams, nil)
+    PK1, SK1 := EKeyGen(sharedParams, nil)
+    PK2, SK2 := EKeyGen(sharedParams, nil)
+
+    id := newTestClientID(pairing)
+    P0 := EEnc(sharedParams, PK0, id, nil)
+
+    token01 := EReKeyToken(sharedParams, SK0, 0, SK1, 1)
+    token12 := EReKeyToken(sharedParams, SK1, 1, SK2, 2)
+    if !EVerifyReKeyToken(sharedParams, PK0, PK1, token01) || EVerifyReKeyToken(sharedParams, PK0, PK2, token01) {
+        t.Error("token 0->1 does not lead from PK0 to PK1 only")
+    }
+    P1, proof01 := EReEncrypt(sharedParams, token01, PK1, P0, nil)
+    if !reflect.DeepEqual(id, EDec(sharedParams, SK1, P1)) {
+        t.Error("re-encryption 0->1 changed the client id")
+    }
+    if bytes.Equal(P0.C, P1.C) {
+        t.Error("re-encryption 0->1 is not rerandomized")
+    }
+    if !VerifyReEncryption(sharedParams, PK0, PK1, P0, P1, proof01) {
+        t.Error("re-encryption 0->1 rejected")
+    }
+
+    token02, err := ECombineReKeyTokens(sharedParams, token01, token12)
+    if err != nil {
+        t.Fatal(err)
+    }
+    P2, proof02 := EReEncrypt(sharedParams, token02, PK2, P0, nil)
+    if !reflect.DeepEqual(id, EDec(sharedParams, SK2, P2)) {
+        t.Error("re-encryption 0->2 changed the client id")
+    }
+    if !VerifyReEncryption(sharedParams, PK0, PK2, P0, P2, proof02) {
+        t.Error("re-encryption 0->2 rejected")
+    }
+    if VerifyReEncryption(sharedParams, PK0, PK1, P0, P2, proof02) {
+        t.Error("wrong key accepted")
+    }
+    other := EEnc(sharedParams, PK0, newTestClientID(pairing), nil)
+    if VerifyReEncryption(sharedParams, PK0, PK2, other, P2, proof02) {
+        t.Error("re-encryption of another pseudonym accepted")
+    }
+    tampered := *proof02
+    tampered.RerandResponse = proof01.RerandResponse
+    if VerifyReEncryption(sharedParams, PK0, PK2, P0, P2, &tampered) {
+        t.Error("tampered proof accepted")
+    }
+
+    _, err = ECombineReKeyTokens(sharedParams, token12, token01)
+    if err == nil {
+        t.Error("tokens combined out of order")
+    }
+}
+
+/*
+ * A key pair, a pseudonym and its rerandomization for the benchmarks
+ */
+type rerandomizationBench struct {
+    sharedParams *SharedParams
+    PK *AuditorPublicKey
+    SK *AuditorSecretKey
+    id *ClientID
+    P *Pseudonym
+    Pprime *Pseudonym
+    rprime []byte
+}
+
+func newRerandomizationBench() *rerandomizationBench {
+    bench := new(rerandomizationBench)
+    bench.sharedParams = GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(bench.sharedParams.Params)
+    bench.PK, bench.SK = EKeyGen(bench.sharedParams, nil)
+    bench.id = newTestClientID(pairing)
+    bench.P = EEnc(bench.sharedParams, bench.PK, bench.id, nil)
+    bench.Pprime, bench.rprime = ERerand(bench.sharedParams, bench.PK, bench.P, nil)
+    return bench
+}
+
+func BenchmarkEKeyGen(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        EKeyGen(sharedParams, nil)
+    }
+}
+
+func BenchmarkEEnc(b *testing.B) {
+    bench := newRerandomizationBench()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        EEnc(bench.sharedParams, bench.PK, bench.id, nil)
+    }
+}
+
+func BenchmarkEDec(b *testing.B) {
+    bench := newRerandomizationBench()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        EDec(bench.sharedParams, bench.SK, bench.P)
+    }
+}
+
+func BenchmarkERerand(b *testing.B) {
+    bench := newRerandomizationBench()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        ERerand(bench.sharedParams, bench.PK, bench.P, nil)
+    }
+}
+
+func BenchmarkERerandVerify(b *testing.B) {
+    bench := newRerandomizationBench()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        ERerandVerify(bench.sharedParams, bench.SK, bench.P, bench.Pprime)
+    }
+}
+
+func BenchmarkERerandProve(b *testing.B) {
+    bench := newRerandomizationBench()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        ERerandProve(bench.sharedParams, bench.PK, bench.P, bench.Pprime, bench.rprime, nil)
+    }
+}
+
+func BenchmarkERerandVerifyPublic(b *testing.B) {
+    bench := newRerandomizationBench()
+    proof := ERerandProve(bench.sharedParams, bench.PK, bench.P, bench.Pprime, bench.rprime, nil)
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        ERerandVerifyPublic(bench.sharedParams, bench.PK, bench.P, bench.Pprime, proof)
+    }
+}
+
+func BenchmarkEEncWithProof(b *testing.B) {
+    bench := newRerandomizationBench()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        EEncWithProof(bench.sharedParams, bench.PK, bench.id, nil)
+    }
+}
+
+func BenchmarkVerifyEncryption(b *testing.B) {
+    bench := newRerandomizationBench()
+    P, proof := EEncWithProof(bench.sharedParams, bench.PK, bench.id, nil)
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        VerifyEncryption(bench.sharedParams, bench.PK, P, bench.id, proof)
+    }
+}
+
+func BenchmarkEDecWithProof(b *testing.B) {
+    bench := newRerandomizationBench()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        EDecWithProof(bench.sharedParams, bench.SK, bench.P, nil)
+    }
+}
+
+func BenchmarkVerifyDecryption(b *testing.B) {
+    bench := newRerandomizationBench()
+    id, proof := EDecWithProof(bench.sharedParams, bench.SK, bench.P, nil)
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        VerifyDecryption(bench.sharedParams, bench.PK, bench.P, id, proof)
+    }
+}
+
+func BenchmarkEEqualityTest(b *testing.B) {
+    bench := newRerandomizationBench()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        EEqualityTest(bench.sharedParams, bench.SK, bench.P, bench.Pprime, nil)
+    }
+}
+
+func BenchmarkEReEncrypt(b *testing.B) {
+    bench := newRerandomizationBench()
+    PK1, SK1 := EKeyGen(bench.sharedParams, nil)
+    token := EReKeyToken(bench.sharedParams, bench.SK, 0, SK1, 1)
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        EReEncrypt(bench.sharedParams, token, PK1, bench.P, nil)
+    }
+}
@@ -0,0 +1,300 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Threshold variant of the elGamal rerandomization scheme. The auditor
+ * secret key is shared t-of-n among n auditors by a distributed key
+ * generation (joint Feldman): every auditor deals shares of a random
+ * polynomial and the secret key is the sum of the constant terms, which
+ * no one ever learns. Any t auditors decrypt a pseudonym together by
+ * publishing verifiable partial decryptions.
+ */
+
+package ocert
+
+import (
+    "fmt"
+    "io"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * Run by auditor dealer (1 ... n) as the first step of the distributed
+ * key generation. It returns the public deal, to be published to all
+ * auditors, and one share for each auditor 1 ... n, to be sent privately.
+ */
+func EDKGDeal(sharedParams *SharedParams, dealer int, t int, n int, rand io.Reader) (*AuditorDeal, []*AuditorDealShare, error) {
+    if t < 1 || t > n {
+        return nil, nil, fmt.Errorf("Invalid threshold %d of %d", t, n)
+    }
+    if dealer < 1 || dealer > n {
+        return nil, nil, fmt.Errorf("Invalid dealer index %d", dealer)
+    }
+
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+
+    // Random polynomial of degree t - 1
+    coefficients := make([]*pbc.Element, t)
+    deal := new(AuditorDeal)
+    deal.Dealer = dealer
+    deal.Commitments = make([][]byte, t)
+    for k := 0; k < t; k++ {
+        coefficients[k] = randZr(pairing, rand)
+        deal.Commitments[k] = pairing.NewG1().MulZn(g1, coefficients[k]).Bytes()
+    }
+
+    shares := make([]*AuditorDealShare, n)
+    for j := 1; j <= n; j++ {
+        share := new(AuditorDealShare)
+        share.Dealer = dealer
+        share.Recipient = j
+        share.Share = evalPolynomial(pairing, coefficients, j).Bytes()
+        shares[j - 1] = share
+    }
+    return deal, shares, nil
+}
+
+/*
+ * Check a share received from a dealer against the dealer's public
+ * commitments. An auditor that receives an invalid share should publish
+ * a complaint so that the dealer is excluded by all auditors.
+ */
+func EDKGVerifyShare(sharedParams *SharedParams, deal *AuditorDeal, share *AuditorDealShare) error {
+    if deal.Dealer != share.Dealer {
+        return fmt.Errorf("Share from dealer %d does not match deal from dealer %d", share.Dealer, deal.Dealer)
+    }
+    if len(deal.Commitments) == 0 {
+        return fmt.Errorf("Deal from dealer %d has no commitments", deal.Dealer)
+    }
+
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+
+    s := pairing.NewZr().SetBytes(share.Share)
+    expected := evalCommitments(pairing, deal.Commitments, share.Recipient)
+    if !pairing.NewG1().MulZn(g1, s).Equals(expected) {
+        return fmt.Errorf("Invalid share from dealer %d for auditor %d", share.Dealer, share.Recipient)
+    }
+    return nil
+}
+
+/*
+ * Combine the deals of all qualified dealers into the threshold auditor
+ * public key. Every auditor and the chaincode compute the same key from
+ * the published deals.
+ */
+func EDKGPublicKey(sharedParams *SharedParams, t int, n int, deals []*AuditorDeal) (*ThresholdAuditorPublicKey, error) {
+    if t < 1 || t > n {
+        return nil, fmt.Errorf("Invalid threshold %d of %d", t, n)
+    }
+    if len(deals) == 0 {
+        return nil, fmt.Errorf("No deals")
+    }
+
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    commitments := make([]*pbc.Element, t)
+    for k := 0; k < t; k++ {
+        commitments[k] = pairing.NewG1().Set0()
+    }
+    dealers := make(map[int]bool)
+    for _, deal := range deals {
+        if deal.Dealer < 1 || deal.Dealer > n || dealers[deal.Dealer] {
+            return nil, fmt.Errorf("Invalid or duplicate dealer %d", deal.Dealer)
+        }
+        dealers[deal.Dealer] = true
+        if len(deal.Commitments) != t {
+            return nil, fmt.Errorf("Deal from dealer %d has %d commitments, expecting %d",
+                deal.Dealer, len(deal.Commitments), t)
+        }
+        for k := 0; k < t; k++ {
+            commitments[k] = pairing.NewG1().Add(commitments[k], pairing.NewG1().SetBytes(deal.Commitments[k]))
+        }
+    }
+
+    TPKa := new(ThresholdAuditorPublicKey)
+    TPKa.Threshold = t
+    TPKa.N = n
+    TPKa.PK = commitments[0].Bytes()
+    TPKa.Commitments = make([][]byte, t)
+    for k := 0; k < t; k++ {
+        TPKa.Commitments[k] = commitments[k].Bytes()
+    }
+    return TPKa, nil
+}
+
+/*
+ * Combine the shares an auditor received from all qualified dealers into
+ * its share of the auditor secret key. shares must contain exactly one
+ * valid share for the auditor from each dealer in deals.
+ */
+func EDKGKeyShare(sharedParams *SharedParams, index int, deals []*AuditorDeal, shares []*AuditorDealShare) (*AuditorKeyShare, error) {
+    if len(deals) != len(shares) {
+        return nil, fmt.Errorf("Expecting one share per deal, got %d shares for %d deals", len(shares), len(deals))
+    }
+
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    received := make(map[int]*AuditorDealShare)
+    for _, share := range shares {
+        if share.Recipient != index {
+            return nil, fmt.Errorf("Share from dealer %d is for auditor %d", share.Dealer, share.Recipient)
+        }
+        received[share.Dealer] = share
+    }
+
+    x := pairing.NewZr().Set0()
+    for _, deal := range deals {
+        share, ok := received[deal.Dealer]
+        if !ok {
+            return nil, fmt.Errorf("Missing share from dealer %d", deal.Dealer)
+        }
+        err := EDKGVerifyShare(sharedParams, deal, share)
+        if err != nil {
+            return nil, err
+        }
+        x = pairing.NewZr().Add(x, pairing.NewZr().SetBytes(share.Share))
+    }
+
+    keyShare := new(AuditorKeyShare)
+    keyShare.Index = index
+    keyShare.SK = x.Bytes()
+    return keyShare, nil
+}
+
+/*
+ * Compute x_i * C for a pseudonym P = (C, D) with the auditor's key share
+ * and prove that it was computed with the share committed to in the
+ * threshold public key
+ */
+func EPartialDec(sharedParams *SharedParams, share *AuditorKeyShare, P *Pseudonym, rand io.Reader) *PartialDecryption {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+
+    C := pairing.NewG1().SetBytes(P.C)
+    x := pairing.NewZr().SetBytes(share.SK)
+    X := pairing.NewG1().MulZn(g1, x)
+    XC := pairing.NewG1().MulZn(C, x)
+
+    partial := new(PartialDecryption)
+    partial.Index = share.Index
+    partial.XC = XC.Bytes()
+    partial.Proof = dleqProve(pairing, g1, X, C, XC, x, rand)
+    return partial
+}
+
+/*
+ * Verify a partial decryption of P against the verification key of the
+ * auditor's share
+ */
+func EPartialDecVerify(sharedParams *SharedParams, TPKa *ThresholdAuditorPublicKey, P *Pseudonym, partial *PartialDecryption) bool {
+    if partial.Index < 1 || partial.Index > TPKa.N {
+        return false
+    }
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+
+    X := evalCommitments(pairing, TPKa.Commitments, partial.Index)
+    C := pairing.NewG1().SetBytes(P.C)
+    XC := pairing.NewG1().SetBytes(partial.XC)
+    return dleqVerify(pairing, g1, X, C, XC, partial.Proof)
+}
+
+/*
+ * Decrypt the client id from the partial decryptions of at least t
+ * auditors, which gives the same result as EDec with the secret key that
+ * is never reconstructed. Invalid partial decryptions are skipped.
+ */
+func EThresholdDec(sharedParams *SharedParams, TPKa *ThresholdAuditorPublicKey, P *Pseudonym, partials []*PartialDecryption) (*ClientID, error) {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    // Use the first t valid partial decryptions of distinct auditors
+    var used []*PartialDecryption
+    seen := make(map[int]bool)
+    for _, partial := range partials {
+        if len(used) == TPKa.Threshold {
+            break
+        }
+        if seen[partial.Index] {
+            continue
+        }
+        if !EPartialDecVerify(sharedParams, TPKa, P, partial) {
+            continue
+        }
+        seen[partial.Index] = true
+        used = append(used, partial)
+    }
+    if len(used) < TPKa.Threshold {
+        return nil, fmt.Errorf("Need %d valid partial decryptions, got %d", TPKa.Threshold, len(used))
+    }
+
+    // x * C = sum of lambda_i * x_i * C
+    xC := pairing.NewG1().Set0()
+    for _, partial := range used {
+        lambda := lagrangeCoefficient(pairing, used, partial.Index)
+        XC := pairing.NewG1().SetBytes(partial.XC)
+        xC = pairing.NewG1().Add(xC, pairing.NewG1().MulZn(XC, lambda))
+    }
+
+    id := new(ClientID)
+    D := pairing.NewG1().SetBytes(P.D)
+    id.ID = pairing.NewG1().Sub(D, xC).Bytes()
+    return id, nil
+}
+
+/*
+ * f(j) = a_0 + a_1 * j + ... + a_{t-1} * j^{t-1} in Zr
+ */
+func evalPolynomial(pairing *pbc.Pairing, coefficients []*pbc.Element, j int) *pbc.Element {
+    jZr := pairing.NewZr().SetInt32(int32(j))
+    y := pairing.NewZr().Set(coefficients[len(coefficients) - 1])
+    for k := len(coefficients) - 2; k >= 0; k-- {
+        y = pairing.NewZr().Add(pairing.NewZr().Mul(y, jZr), coefficients[k])
+    }
+    return y
+}
+
+/*
+ * f(j) * g1 computed from the commitments a_k * g1 in G1
+ */
+func evalCommitments(pairing *pbc.Pairing, commitments [][]byte, j int) *pbc.Element {
+    jZr := pairing.NewZr().SetInt32(int32(j))
+    Y := pairing.NewG1().SetBytes(commitments[len(commitments) - 1])
+    for k := len(commitments) - 2; k >= 0; k-- {
+        Y = pairing.NewG1().Add(pairing.NewG1().MulZn(Y, jZr), pairing.NewG1().SetBytes(commitments[k]))
+    }
+    return Y
+}
+
+/*
+ * The Lagrange coefficient of auditor i for interpolating at 0 from the
+ * auditors of the given partial decryptions
+ */
+func lagrangeCoefficient(pairing *pbc.Pairing, partials []*PartialDecryption, i int) *pbc.Element {
+    iZr := pairing.NewZr().SetInt32(int32(i))
+    lambda := pairing.NewZr().Set1()
+    for _, partial := range partials {
+        if partial.Index == i {
+            continue
+        }
+        mZr := pairing.NewZr().SetInt32(int32(partial.Index))
+        lambda = pairing.NewZr().Mul(lambda, pairing.NewZr().Div(mZr, pairing.NewZr().Sub(mZr, iZr)))
+    }
+    return lambda
+}
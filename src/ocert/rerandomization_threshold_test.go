@@ -0,0 +1,155 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "reflect"
+    "testing"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * A t-of-n auditor key from a distributed key generation
+ */
+type thresholdAuditors struct {
+    deals []*AuditorDeal
+    received [][]*AuditorDealShare
+    TPKa *ThresholdAuditorPublicKey
+    keyShares []*AuditorKeyShare
+}
+
+func newThresholdAuditors(sharedParams *SharedParams, t int, n int) (*thresholdAuditors, error) {
+    auditors := new(thresholdAuditors)
+    auditors.deals = make([]*AuditorDeal, n)
+    auditors.received = make([][]*AuditorDealShare, n)
+    for i := 1; i <= n; i++ {
+        deal, shares, err := EDKGDeal(sharedParams, i, t, n, nil)
+        if err != nil {
+            return nil, err
+        }
+        auditors.deals[i - 1] = deal
+        for _, share := range shares {
+            auditors.received[share.Recipient - 1] = append(auditors.received[share.Recipient - 1], share)
+        }
+    }
+
+    var err error
+    auditors.TPKa, err = EDKGPublicKey(sharedParams, t, n, auditors.deals)
+    if err != nil {
+        return nil, err
+    }
+    auditors.keyShares = make([]*AuditorKeyShare, n)
+    for i := 1; i <= n; i++ {
+        auditors.keyShares[i - 1], err = EDKGKeyShare(sharedParams, i, auditors.deals, auditors.received[i - 1])
+        if err != nil {
+            return nil, err
+        }
+    }
+    return auditors, nil
+}
+
+/*
+ * Generate a 3-of-5 auditor key, decrypt a pseudonym with three auditors
+ * and check that two auditors or a forged partial decryption are not
+ * enough
+ */
+func TestThresholdDecryption(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    auditors, err := newThresholdAuditors(sharedParams, 3, 5)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // A tampered share is detected
+    bad := *auditors.received[0][1]
+    bad.Share = pairing.NewZr().Rand().Bytes()
+    if EDKGVerifyShare(sharedParams, auditors.deals[1], &bad) == nil {
+        t.Error("tampered share accepted")
+    }
+
+    id := newTestClientID(pairing)
+    P := EEnc(sharedParams, auditors.TPKa.AuditorPublicKey(), id, nil)
+    P, _ = ERerand(sharedParams, auditors.TPKa.AuditorPublicKey(), P, nil)
+
+    partials := []*PartialDecryption{
+        EPartialDec(sharedParams, auditors.keyShares[4], P, nil),
+        EPartialDec(sharedParams, auditors.keyShares[1], P, nil),
+        EPartialDec(sharedParams, auditors.keyShares[2], P, nil),
+    }
+    decryptID, err := EThresholdDec(sharedParams, auditors.TPKa, P, partials)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(id, decryptID) {
+        t.Error("threshold decryption does not give the client id")
+    }
+
+    _, err = EThresholdDec(sharedParams, auditors.TPKa, P, partials[:2])
+    if err == nil {
+        t.Error("two auditors decrypted")
+    }
+
+    // Auditor 1 claims a partial decryption computed with a random key
+    forged := EPartialDec(sharedParams, auditors.keyShares[0], P, nil)
+    forged.XC = pairing.NewG1().Rand().Bytes()
+    _, err = EThresholdDec(sharedParams, auditors.TPKa, P, append(partials[:2], forged))
+    if err == nil {
+        t.Error("forged partial decryption accepted")
+    }
+}
+
+func BenchmarkEDKGDeal(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        EDKGDeal(sharedParams, 1, 3, 5, nil)
+    }
+}
+
+func BenchmarkEPartialDec(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    auditors, err := newThresholdAuditors(sharedParams, 3, 5)
+    if err != nil {
+        b.Fatal(err)
+    }
+    P := EEnc(sharedParams, auditors.TPKa.AuditorPublicKey(), newTestClientID(pairing), nil)
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        EPartialDec(sharedParams, auditors.keyShares[0], P, nil)
+    }
+}
+
+func BenchmarkEThresholdDec(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    auditors, err := newThresholdAuditors(sharedParams, 3, 5)
+    if err != nil {
+        b.Fatal(err)
+    }
+    P := EEnc(sharedParams, auditors.TPKa.AuditorPublicKey(), newTestClientID(pairing), nil)
+    partials := []*PartialDecryption{}
+    for _, share := range auditors.keyShares[:3] {
+        partials = append(partials, EPartialDec(sharedParams, share, P, nil))
+    }
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        EThresholdDec(sharedParams, auditors.TPKa, P, partials)
+    }
+}
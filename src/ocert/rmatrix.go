@@ -21,10 +21,12 @@ package ocert
 import (
     "github.com/Nik-U/pbc"
     "fmt"
+    "io"
 )
 
 /*
- * RMatrix: Builds a matrix of random elements from Zp
+ * RMatrix: Builds a matrix of random elements from Zp, drawn from rand,
+ * see randZr
  */
 
 type BMatrix struct {
@@ -41,14 +43,14 @@ type RMatrix struct {
     invert bool
 }
 
-func NewRMatrix(pairing *pbc.Pairing, rows int, cols int) *RMatrix {
+func NewRMatrix(pairing *pbc.Pairing, rows int, cols int, rand io.Reader) *RMatrix {
     rmat := new(RMatrix)
     rmat.rows = rows
     rmat.cols = cols
     for i := 0; i < rows; i++ {
         elementRow := []*pbc.Element{}
         for j := 0; j < cols; j++ {
-            el := pairing.NewZr().Rand()
+            el := randZr(pairing, rand)
             elementRow = append(elementRow, el)
         }
         rmat.mat = append(rmat.mat, elementRow)
@@ -57,14 +59,14 @@ func NewRMatrix(pairing *pbc.Pairing, rows int, cols int) *RMatrix {
 }
 
 
-func NewRMatrixinG2(pairing *pbc.Pairing, rows int, cols int) *RMatrix {
+func NewRMatrixinG2(pairing *pbc.Pairing, rows int, cols int, rand io.Reader) *RMatrix {
     rmat := new(RMatrix)
     rmat.rows = rows
     rmat.cols = cols
     for i := 0; i < rows; i++ {
         elementRow := []*pbc.Element{}
         for j := 0; j < cols; j++ {
-            el := pairing.NewG2().Rand()
+            el := randG2(pairing, rand)
             elementRow = append(elementRow, el)
         }
         rmat.mat = append(rmat.mat, elementRow)
@@ -72,14 +74,14 @@ func NewRMatrixinG2(pairing *pbc.Pairing, rows int, cols int) *RMatrix {
     return rmat
 }
 
-func NewRMatrixinG1(pairing *pbc.Pairing, rows int, cols int) *RMatrix {
+func NewRMatrixinG1(pairing *pbc.Pairing, rows int, cols int, rand io.Reader) *RMatrix {
     rmat := new(RMatrix)
     rmat.rows = rows
     rmat.cols = cols
     for i := 0; i < rows; i++ {
         elementRow := []*pbc.Element{}
         for j := 0; j < cols; j++ {
-            el := pairing.NewG1().Rand()
+            el := randG1(pairing, rand)
             elementRow = append(elementRow, el)
         }
         rmat.mat = append(rmat.mat, elementRow)
@@ -287,7 +289,7 @@ func (rmat *RMatrix) ElementWiseSub(pairing *pbc.Pairing, L *RMatrix) *RMatrix {
         panic("Rows and Cols need to be equivalent")
     }
 
-    R := NewRMatrix(pairing, L.rows, L.cols)
+    R := NewRMatrix(pairing, L.rows, L.cols, nil)
     for i := 0; i < L.rows; i++{
         for j := 0; j < L.cols; j++ {
             R.mat[i][j] = pairing.NewZr().Sub(rmat.mat[i][j], L.mat[i][j])
@@ -0,0 +1,368 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "fmt"
+    "reflect"
+    "testing"
+    "github.com/Nik-U/pbc"
+)
+
+type rmatrixSize struct {
+    rows int
+    cols int
+}
+
+var rmatrixSizes = []rmatrixSize{
+    {1, 1},
+    {10, 1},
+    {10, 10},
+}
+
+/*
+ * Dimensions of R (r_rows x r_cols) and X (x_rows x x_cols) in R * X
+ */
+var rmatrixProductSizes = []struct {
+    rRows int
+    rCols int
+    xRows int
+    xCols int
+}{
+    {1, 1, 1, 1},
+    {2, 1, 1, 2},
+    {1, 2, 2, 1},
+    {2, 2, 2, 1},
+    {1, 2, 2, 2},
+    {2, 2, 2, 2},
+}
+
+func sizeName(dims ...int) string {
+    name := ""
+    for i := 0; i < len(dims); i += 2 {
+        if name != "" {
+            name += "_"
+        }
+        name += fmt.Sprintf("%dx%d", dims[i], dims[i + 1])
+    }
+    return name
+}
+
+func checkRMatrixStructure(t *testing.T, R *RMatrix) {
+    if R.rows != len(R.mat) || R.cols != len(R.mat[0]) {
+        t.Errorf("RMatrix is %dx%d but holds %dx%d elements", R.rows, R.cols, len(R.mat), len(R.mat[0]))
+    }
+}
+
+func checkBMatrixStructure(t *testing.T, B *BMatrix) {
+    if B.rows != len(B.mat) || B.cols != len(B.mat[0]) {
+        t.Errorf("BMatrix is %dx%d but holds %dx%d elements", B.rows, B.cols, len(B.mat), len(B.mat[0]))
+    }
+}
+
+/*
+ * A random matrix of B-pairs in G1 or G2
+ */
+func newTestBMatrix(newElement func() *pbc.Element, rows int, cols int) *BMatrix {
+    X := new(BMatrix)
+    for i := 0; i < rows; i++ {
+        row := []*BPair{}
+        for j := 0; j < cols; j++ {
+            row = append(row, &BPair{newElement().Rand().Bytes(), newElement().Rand().Bytes()})
+        }
+        X.mat = append(X.mat, row)
+    }
+    X.rows = rows
+    X.cols = cols
+    return X
+}
+
+/*
+ * Row i of R times the commitment keys is sum_j r_ij * U_j
+ */
+func TestRMatrixMulCommitmentKeys(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    alpha := pairing.NewZr().Rand()
+    sigma := CreateCommonReferenceString(sharedParams, alpha, nil)
+    R := NewRMatrix(pairing, 3, 2, nil)
+    checkRMatrixStructure(t, R)
+
+    groups := []struct {
+        name string
+        newElement func() *pbc.Element
+        keys []CommitmentKey
+        mul func(*pbc.Pairing, []CommitmentKey) []*BPair
+    }{
+        {"G1", pairing.NewG1, sigma.U, R.MulCommitmentKeysG1},
+        {"G2", pairing.NewG2, sigma.V, R.MulCommitmentKeysG2},
+    }
+    for _, group := range groups {
+        t.Run(group.name, func(t *testing.T) {
+            Ru := group.mul(pairing, group.keys)
+            if len(Ru) != R.rows {
+                t.Fatalf("got %d rows, want %d", len(Ru), R.rows)
+            }
+            for i := 0; i < R.rows; i++ {
+                b1 := group.newElement().Set0()
+                b2 := group.newElement().Set0()
+                for j := 0; j < R.cols; j++ {
+                    b1.ThenAdd(group.newElement().MulZn(group.newElement().SetBytes(group.keys[j].u1), R.mat[i][j]))
+                    b2.ThenAdd(group.newElement().MulZn(group.newElement().SetBytes(group.keys[j].u2), R.mat[i][j]))
+                }
+                if !b1.Equals(group.newElement().SetBytes(Ru[i].b1)) || !b2.Equals(group.newElement().SetBytes(Ru[i].b2)) {
+                    t.Errorf("row %d differs", i)
+                }
+            }
+        })
+    }
+}
+
+func TestRMatrixMulScalarZn(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    for _, size := range rmatrixSizes {
+        t.Run(sizeName(size.rows, size.cols), func(t *testing.T) {
+            R := NewRMatrix(pairing, size.rows, size.cols, nil)
+            x := pairing.NewZr().Rand()
+            xR := R.MulScalarZn(pairing, x)
+            checkRMatrixStructure(t, xR)
+            for i := 0; i < R.rows; i++ {
+                for j := 0; j < R.cols; j++ {
+                    if !pairing.NewZr().Mul(R.mat[i][j], x).Equals(xR.mat[i][j]) {
+                        t.Errorf("[%d, %d] differs", i, j)
+                    }
+                }
+            }
+        })
+    }
+}
+
+func TestRMatrixElementWiseSub(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    for _, size := range rmatrixSizes {
+        t.Run(sizeName(size.rows, size.cols), func(t *testing.T) {
+            L := NewRMatrix(pairing, size.rows, size.cols, nil)
+            R := NewRMatrix(pairing, size.rows, size.cols, nil)
+            LR := L.ElementWiseSub(pairing, R)
+            checkRMatrixStructure(t, LR)
+            for i := 0; i < L.rows; i++ {
+                for j := 0; j < L.cols; j++ {
+                    if !pairing.NewZr().Sub(L.mat[i][j], R.mat[i][j]).Equals(LR.mat[i][j]) {
+                        t.Errorf("[%d, %d] differs", i, j)
+                    }
+                }
+            }
+        })
+    }
+}
+
+func TestRMatrixMulBScalarinB1(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    for _, size := range rmatrixSizes {
+        t.Run(sizeName(size.rows, size.cols), func(t *testing.T) {
+            R := NewRMatrix(pairing, size.rows, size.cols, nil)
+            B := BPair{pairing.NewG1().Rand().Bytes(), pairing.NewG1().Rand().Bytes()}
+            Rb := R.MulBScalarinB1(pairing, B)
+            if len(Rb) != R.rows || len(Rb[0]) != R.cols {
+                t.Fatalf("got %dx%d, want %dx%d", len(Rb), len(Rb[0]), R.rows, R.cols)
+            }
+            for i := 0; i < R.rows; i++ {
+                for j := 0; j < R.cols; j++ {
+                    b1 := pairing.NewG1().MulZn(pairing.NewG1().SetBytes(B.b1), R.mat[i][j])
+                    b2 := pairing.NewG1().MulZn(pairing.NewG1().SetBytes(B.b2), R.mat[i][j])
+                    if !b1.Equals(pairing.NewG1().SetBytes(Rb[i][j].b1)) || !b2.Equals(pairing.NewG1().SetBytes(Rb[i][j].b2)) {
+                        t.Errorf("[%d, %d] differs", i, j)
+                    }
+                }
+            }
+        })
+    }
+}
+
+func TestRMatrixInvert(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    sizes := []rmatrixSize{{1, 1}, {10, 1}, {3, 7}, {4, 8}}
+    for _, size := range sizes {
+        t.Run(sizeName(size.rows, size.cols), func(t *testing.T) {
+            R := NewRMatrix(pairing, size.rows, size.cols, nil)
+            Ri := R.InvertMatrix()
+            checkRMatrixStructure(t, Ri)
+            if Ri.rows != R.cols || Ri.cols != R.rows {
+                t.Fatalf("got %dx%d, want %dx%d", Ri.rows, Ri.cols, R.cols, R.rows)
+            }
+            for i := 0; i < R.rows; i++ {
+                for j := 0; j < R.cols; j++ {
+                    if !R.mat[i][j].Equals(Ri.mat[j][i]) {
+                        t.Errorf("[%d, %d] differs", i, j)
+                    }
+                }
+            }
+        })
+    }
+}
+
+/*
+ * R * X for X in Zr, G1 and G2, checked against the sum over k of
+ * X[k][j] * R[i][k]
+ */
+func TestRMatrixMultElementArray(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    groups := []struct {
+        name string
+        newElement func() *pbc.Element
+        newX func(*pbc.Pairing, int, int) *RMatrix
+        mult func(*RMatrix, *pbc.Pairing, [][]*pbc.Element) *RMatrix
+    }{
+        {
+            "Zr",
+            pairing.NewZr,
+            func(pairing *pbc.Pairing, rows int, cols int) *RMatrix { return NewRMatrix(pairing, rows, cols, nil) },
+            (*RMatrix).MultElementArrayZr,
+        },
+        {
+            "G1",
+            pairing.NewG1,
+            func(pairing *pbc.Pairing, rows int, cols int) *RMatrix { return NewRMatrixinG1(pairing, rows, cols, nil) },
+            (*RMatrix).MultElementArrayG1,
+        },
+        {
+            "G2",
+            pairing.NewG2,
+            func(pairing *pbc.Pairing, rows int, cols int) *RMatrix { return NewRMatrixinG2(pairing, rows, cols, nil) },
+            (*RMatrix).MultElementArrayG2,
+        },
+    }
+    for _, group := range groups {
+        for _, size := range rmatrixProductSizes {
+            t.Run(group.name + "/" + sizeName(size.rRows, size.rCols, size.xRows, size.xCols), func(t *testing.T) {
+                R := NewRMatrix(pairing, size.rRows, size.rCols, nil)
+                X := group.newX(pairing, size.xRows, size.xCols)
+                RX := group.mult(R, pairing, X.mat)
+                checkRMatrixStructure(t, RX)
+                if len(RX.mat) != size.rRows || len(RX.mat[0]) != size.xCols {
+                    t.Fatalf("got %dx%d, want %dx%d", len(RX.mat), len(RX.mat[0]), size.rRows, size.xCols)
+                }
+                for i := 0; i < size.rRows; i++ {
+                    for j := 0; j < size.xCols; j++ {
+                        el := group.newElement().Set0()
+                        for k := 0; k < size.xRows; k++ {
+                            el.ThenAdd(group.newElement().MulZn(X.mat[k][j], R.mat[i][k]))
+                        }
+                        if !el.Equals(RX.mat[i][j]) {
+                            t.Errorf("[%d, %d] differs", i, j)
+                        }
+                    }
+                }
+            })
+        }
+    }
+}
+
+/*
+ * R * X for a matrix X of B-pairs in G1 and G2
+ */
+func TestRMatrixMultBPairMatrix(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    groups := []struct {
+        name string
+        newElement func() *pbc.Element
+        mult func(*RMatrix, *pbc.Pairing, *BMatrix) *BMatrix
+    }{
+        {"G1", pairing.NewG1, (*RMatrix).MultBPairMatrixG1},
+        {"G2", pairing.NewG2, (*RMatrix).MultBPairMatrixG2},
+    }
+    for _, group := range groups {
+        for _, size := range rmatrixProductSizes {
+            t.Run(group.name + "/" + sizeName(size.rRows, size.rCols, size.xRows, size.xCols), func(t *testing.T) {
+                R := NewRMatrix(pairing, size.rRows, size.rCols, nil)
+                X := newTestBMatrix(group.newElement, size.xRows, size.xCols)
+                RX := group.mult(R, pairing, X)
+                checkBMatrixStructure(t, RX)
+                if len(RX.mat) != size.rRows || len(RX.mat[0]) != size.xCols {
+                    t.Fatalf("got %dx%d, want %dx%d", len(RX.mat), len(RX.mat[0]), size.rRows, size.xCols)
+                }
+                for i := 0; i < size.rRows; i++ {
+                    for j := 0; j < size.xCols; j++ {
+                        el := &BPair{group.newElement().Set1().Bytes(), group.newElement().Set1().Bytes()}
+                        for k := 0; k < size.xRows; k++ {
+                            b1 := group.newElement().MulZn(group.newElement().SetBytes(X.mat[k][j].b1), R.mat[i][k])
+                            b2 := group.newElement().MulZn(group.newElement().SetBytes(X.mat[k][j].b2), R.mat[i][k])
+                            el.b1 = group.newElement().Add(group.newElement().SetBytes(el.b1), b1).Bytes()
+                            el.b2 = group.newElement().Add(group.newElement().SetBytes(el.b2), b2).Bytes()
+                        }
+                        if !reflect.DeepEqual(el, RX.mat[i][j]) {
+                            t.Errorf("[%d, %d] differs", i, j)
+                        }
+                    }
+                }
+            })
+        }
+    }
+}
+
+func BenchmarkNewRMatrix(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        NewRMatrix(pairing, 2, 2, nil)
+    }
+}
+
+func BenchmarkRMatrixMulCommitmentKeys(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    sigma := CreateCommonReferenceString(sharedParams, pairing.NewZr().Rand(), nil)
+    R := NewRMatrix(pairing, 4, 2, nil)
+    b.Run("G1", func(b *testing.B) {
+        for i := 0; i < b.N; i++ {
+            R.MulCommitmentKeysG1(pairing, sigma.U)
+        }
+    })
+    b.Run("G2", func(b *testing.B) {
+        for i := 0; i < b.N; i++ {
+            R.MulCommitmentKeysG2(pairing, sigma.V)
+        }
+    })
+}
+
+func BenchmarkRMatrixMultBPairMatrix(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    R := NewRMatrix(pairing, 2, 2, nil)
+    X1 := newTestBMatrix(pairing.NewG1, 2, 2)
+    X2 := newTestBMatrix(pairing.NewG2, 2, 2)
+    b.Run("G1", func(b *testing.B) {
+        for i := 0; i < b.N; i++ {
+            R.MultBPairMatrixG1(pairing, X1)
+        }
+    })
+    b.Run("G2", func(b *testing.B) {
+        for i := 0; i < b.N; i++ {
+            R.MultBPairMatrixG2(pairing, X2)
+        }
+    })
+}
@@ -24,66 +24,243 @@
 package ocert
 
 import (
+    "fmt"
+    "crypto/sha256"
+    "io"
     "github.com/Nik-U/pbc"
 )
 
+/*
+ * Names of the structure-preserving schemes, stored in SVerificationKey.Scheme
+ */
+const (
+    SPSSchemeAGHO     = "agho"
+    SPSSchemeDualAGHO = "agho-dual"
+)
+
+/*
+ * SPSScheme is a structure-preserving signature scheme used to sign the
+ * client pseudonym P = (C, D) in G1 * G1 and the client public key PKc in G2.
+ * Besides KeyGen, Sign and Verify, a scheme describes the pairing product
+ * equations that hold for a valid ecert, which are the equations the client
+ * has to prove in zero knowledge in GenOCert.
+ */
+type SPSScheme interface {
+    Name() string
+    KeyGen(sharedParams *SharedParams, rand io.Reader) (*SVerificationKey, *SSigningKey)
+    Sign(sharedParams *SharedParams, SK *SSigningKey, P *Pseudonym, PKc *ClientPublicKey, epoch *Epoch, rand io.Reader) *Ecert
+    Verify(sharedParams *SharedParams, VK *SVerificationKey, P *Pseudonym, PKc *ClientPublicKey, epoch *Epoch, ecert *Ecert) bool
+    Equations() []SPSEquation
+}
+
+/*
+ * A single pairing e(A, B) in a pairing product equation, A is named from
+ * G1 and B is named from G2. AVar and BVar tell whether the element is a
+ * witness of the client (and thus committed in the proof) or a constant
+ * known to the verifier.
+ */
+type SPSPairing struct {
+    A    string
+    AVar bool
+    B    string
+    BVar bool
+}
+
+/*
+ * A pairing product equation prod_i e(A_i, B_i) = Target, where Target is
+ * a constant in GT
+ */
+type SPSEquation struct {
+    Pairings []SPSPairing
+    Target   string
+}
+
+/*
+ * Returns the scheme with the given name. An empty name is the scheme used
+ * before the schemes were named, which is AGHO.
+ */
+func GetSPSScheme(name string) (SPSScheme, error) {
+    switch name {
+    case "", SPSSchemeAGHO:
+        return new(AGHOScheme), nil
+    case SPSSchemeDualAGHO:
+        return new(DualAGHOScheme), nil
+    }
+    return nil, fmt.Errorf("Unknown structure preserving scheme: %s", name)
+}
+
+/*
+ * Encodes the validity epoch n as an element E in G1 by hashing it into the
+ * group, so no two epochs have a known relation.
+ */
+func NewEpoch(sharedParams *SharedParams, n uint64) *Epoch {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    epoch := new(Epoch)
+    epoch.Epoch = n
+    epoch.E = pairing.NewG1().SetFromStringHash(fmt.Sprintf("ocert-epoch-%d", n), sha256.New()).Bytes()
+    return epoch
+}
+
+/*
+ * The constant target of the first ecert equation, e(g1, Z) for AGHO and
+ * e(Z, g2) for Dual AGHO, where Z is in G1
+ */
+func SPSEgz(sharedParams *SharedParams, VK *SVerificationKey) []byte {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    G := pairing.NewG1().SetBytes(sharedParams.G1)
+    H := pairing.NewG2().SetBytes(sharedParams.G2)
+    if VK.Scheme == SPSSchemeDualAGHO {
+        return pairing.NewGT().Pair(pairing.NewG1().SetBytes(VK.Z), H).Bytes()
+    }
+    return pairing.NewGT().Pair(G, pairing.NewG2().SetBytes(VK.Z)).Bytes()
+}
+
+/*
+ * Measures the size in bytes of the encoded proof of knowledge a client
+ * sends to GenOCert for an ecert of the scheme, by setting up a proof for
+ * a random client from rand, or from PBC if rand is nil, and encoding it.
+ */
+func SPSProofSize(sharedParams *SharedParams, scheme SPSScheme, rand io.Reader) (int, error) {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    H := pairing.NewG2().SetBytes(sharedParams.G2)
+
+    VK, SK := scheme.KeyGen(sharedParams, rand)
+    PKa, _ := EKeyGen(sharedParams, rand)
+    IDc := new(ClientID)
+    IDc.ID = randG1(pairing, rand).Bytes()
+    xc := randZr(pairing, rand)
+
+    vars := new(ProofVariables)
+    vars.PKa = PKa
+    vars.VK = VK
+    vars.Xc = xc.Bytes()
+    vars.PKc = new(ClientPublicKey)
+    vars.PKc.PK = pairing.NewG2().MulZn(H, xc).Bytes()
+    vars.P = EEnc(sharedParams, PKa, IDc, rand)
+    _, vars.RPrime = ERerand(sharedParams, PKa, vars.P, rand)
+    vars.E = scheme.Sign(sharedParams, SK, vars.P, vars.PKc, NewEpoch(sharedParams, 0), rand)
+
+    value, err := PSetup(sharedParams, vars, rand).Bytes()
+    if err != nil {
+        return 0, err
+    }
+    return len(value), nil
+}
+
+/*
+ * AGHOScheme is the scheme implemented by SKeyGen, SSign and SVerify.
+ */
+type AGHOScheme struct {
+}
+
+func (scheme *AGHOScheme) Name() string {
+    return SPSSchemeAGHO
+}
+
+func (scheme *AGHOScheme) KeyGen(sharedParams *SharedParams, rand io.Reader) (*SVerificationKey, *SSigningKey) {
+    return SKeyGen(sharedParams, rand)
+}
+
+func (scheme *AGHOScheme) Sign(sharedParams *SharedParams, SK *SSigningKey, P *Pseudonym, PKc *ClientPublicKey, epoch *Epoch, rand io.Reader) *Ecert {
+    return SSign(sharedParams, SK, P, PKc, epoch, rand)
+}
+
+func (scheme *AGHOScheme) Verify(sharedParams *SharedParams, VK *SVerificationKey, P *Pseudonym, PKc *ClientPublicKey, epoch *Epoch, ecert *Ecert) bool {
+    return SVerify(sharedParams, VK, P, PKc, epoch, ecert)
+}
+
+/*
+ * e(R, V) * e(S, g2) * e(C, W1) * e(D, W2) = e(g1, Z) / e(E, W3)
+ * e(R, T) * e(U, PKc) = e(g1, g2)
+ * The epoch E is public (it is the current epoch on the ledger), so its
+ * pairing is moved to the target.
+ */
+func (scheme *AGHOScheme) Equations() []SPSEquation {
+    return []SPSEquation{
+        SPSEquation{
+            Pairings: []SPSPairing{
+                SPSPairing{"R", true, "V", false},
+                SPSPairing{"S", true, "g2", false},
+                SPSPairing{"C", true, "W1", false},
+                SPSPairing{"D", true, "W2", false},
+            },
+            Target: "e(g1, Z) / e(E, W3)",
+        },
+        SPSEquation{
+            Pairings: []SPSPairing{
+                SPSPairing{"R", true, "T", true},
+                SPSPairing{"U", false, "PKc", true},
+            },
+            Target: "e(g1, g2)",
+        },
+    }
+}
+
 /*
  * Generate key pair used by orgnization i.
  * SVerificationKey VK is used as ecert verification key VK_e,i for each
  * organization i
  * SSigningKey SK_e,i is used to sign client Pseudonym and ClientPublicKey
- * VK = (U, V, W1, W2, Z) in G1 * G2^4
- * SK = (u, v, w1, w2, z) where u, v, w1, w2, z is randomly picked from group of 
- * units modulo p
- * s.t. U = u * g1, V = v * g2, W1 = w1 * g2, W2 = w2 * g2 and Z = z * g2, where
+ * VK = (U, V, W1, W2, W3, Z) in G1 * G2^5
+ * SK = (u, v, w1, w2, w3, z) where u, v, w1, w2, w3, z is randomly picked from
+ * group of units modulo p
+ * s.t. U = u * g1, V = v * g2, W1 = w1 * g2, W2 = w2 * g2, W3 = w3 * g2 and
+ * Z = z * g2, where
  * g1 is the generator of group G1, and g2 is the generator of group G2
  */
-func SKeyGen(sharedParams *SharedParams) (*SVerificationKey, *SSigningKey) {
+func SKeyGen(sharedParams *SharedParams, rand io.Reader) (*SVerificationKey, *SSigningKey) {
     pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
     g1 := pairing.NewG1().SetBytes(sharedParams.G1)
     g2 := pairing.NewG2().SetBytes(sharedParams.G2)
 
     VK := new(SVerificationKey)
     SK := new(SSigningKey)
-
-    u := pairing.NewZr().Rand()
-    v := pairing.NewZr().Rand()
-    w1 := pairing.NewZr().Rand()
-    w2 := pairing.NewZr().Rand()
-    z := pairing.NewZr().Rand()
+    VK.Scheme = SPSSchemeAGHO
+    SK.Scheme = SPSSchemeAGHO
+
+    u := randZr(pairing, rand)
+    v := randZr(pairing, rand)
+    w1 := randZr(pairing, rand)
+    w2 := randZr(pairing, rand)
+    w3 := randZr(pairing, rand)
+    z := randZr(pairing, rand)
     
     SK.U = u.Bytes()
     SK.V = v.Bytes()
     SK.W1 = w1.Bytes()
     SK.W2 = w2.Bytes()
+    SK.W3 = w3.Bytes()
     SK.Z = z.Bytes()
 
     U := pairing.NewG1().MulZn(g1, u)
     V := pairing.NewG2().MulZn(g2, v)
     W1 := pairing.NewG2().MulZn(g2, w1)
     W2 := pairing.NewG2().MulZn(g2, w2)
+    W3 := pairing.NewG2().MulZn(g2, w3)
     Z := pairing.NewG2().MulZn(g2, z)
 
     VK.U = U.Bytes()
     VK.V = V.Bytes()
     VK.W1 = W1.Bytes()
     VK.W2 = W2.Bytes()
+    VK.W3 = W3.Bytes()
     VK.Z = Z.Bytes()
 
     return VK, SK
 }
 
 /*
- * Signing the pseudonym and public key of a client by the signing key SK_e,i
- * of an organziation i. The output of signing procedure is the ecert.
+ * Signing the pseudonym and public key of a client and the validity epoch by
+ * the signing key SK_e,i of an organziation i. The output of signing
+ * procedure is the ecert.
  * The ecert has format (R, S, T) in G1 * G1 * G2, where g1, g2 are generators
  * of G1 and G2, r is randomly picked from group of units modulo p,
- * SKei = (u, v, w1, w2, z), P = (C, D)
+ * SKei = (u, v, w1, w2, w3, z), P = (C, D), epoch = E
  * R = r * g1
- * S = (z - r * v) * g1 + (-w1) * C + (-w2) * D
+ * S = (z - r * v) * g1 + (-w1) * C + (-w2) * D + (-w3) * E
  * T = (1 / 6) * (g2 + (-u) * PKc)
  */
-func SSign(sharedParams *SharedParams, SKei *SSigningKey, P *Pseudonym, PKc *ClientPublicKey) *Ecert {
+func SSign(sharedParams *SharedParams, SKei *SSigningKey, P *Pseudonym, PKc *ClientPublicKey, epoch *Epoch, rand io.Reader) *Ecert {
     ecert := new(Ecert)
     pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
     g1 := pairing.NewG1().SetBytes(sharedParams.G1)
@@ -101,7 +278,7 @@ func SSign(sharedParams *SharedParams, SKei *SSigningKey, P *Pseudonym, PKc *Cli
     N := pairing.NewG2().SetBytes(PKc.PK)
 
     // Generate R
-    r := pairing.NewZr().Rand()
+    r := randZr(pairing, rand)
     R := pairing.NewG1().MulZn(g1, r)
     ecert.R = R.Bytes()
 
@@ -118,6 +295,13 @@ func SSign(sharedParams *SharedParams, SKei *SSigningKey, P *Pseudonym, PKc *Cli
 
     S := pairing.NewG1().Add(S0, S1)
     S.Add(S, S2)
+
+    if epoch != nil {
+        w3 := pairing.NewZr().SetBytes(SKei.W3)
+        negW3 := pairing.NewZr().Neg(w3)
+        S3 := pairing.NewG1().MulZn(pairing.NewG1().SetBytes(epoch.E), negW3)
+        S.Add(S, S3)
+    }
     ecert.S = S.Bytes()
 
     // Generate T
@@ -135,14 +319,15 @@ func SSign(sharedParams *SharedParams, SKei *SSigningKey, P *Pseudonym, PKc *Cli
 /*
  * Verifying the signature is signed by the client, and returns a boolean, where
  * g1 and g2 are generators of group G1 and G2 respectively
- * VKei = (U, V, W1, W2, Z),
+ * VKei = (U, V, W1, W2, W3, Z),
  * P = (C, D)
+ * epoch = E
  * ecert = (R, S, T),
  * and to verify, test
- * e(R, V) * e(S, g2) * e(C, W1) * e(D, W2) = e(g1, Z) and
+ * e(R, V) * e(S, g2) * e(C, W1) * e(D, W2) * e(E, W3) = e(g1, Z) and
  * e(R, T) * e(U, PKc) = e(g1, g2), where e is the pairing operation
  */
-func SVerify(sharedParams *SharedParams, VKei *SVerificationKey, P *Pseudonym, PKc *ClientPublicKey, ecert *Ecert) bool {
+func SVerify(sharedParams *SharedParams, VKei *SVerificationKey, P *Pseudonym, PKc *ClientPublicKey, epoch *Epoch, ecert *Ecert) bool {
     pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
     g1 := pairing.NewG1().SetBytes(sharedParams.G1)
     g2 := pairing.NewG2().SetBytes(sharedParams.G2)
@@ -173,6 +358,12 @@ func SVerify(sharedParams *SharedParams, VKei *SVerificationKey, P *Pseudonym, P
     LHS1.Mul(LHS1, e3)
     LHS1.Mul(LHS1, e4)
 
+    if epoch != nil {
+        W3 := pairing.NewG2().SetBytes(VKei.W3)
+        eE := pairing.NewGT().Pair(pairing.NewG1().SetBytes(epoch.E), W3)
+        LHS1.Mul(LHS1, eE)
+    }
+
     if !LHS1.Equals(e5) {
         return false
     }
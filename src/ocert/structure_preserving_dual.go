@@ -0,0 +1,235 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * The AGHO scheme of "Optimal Structure-Preserving Signatures in Asymmetric
+ * Bilinear Groups" with the roles of G1 and G2 swapped. The signature has
+ * the same size as AGHO (three group elements and two pairing product
+ * equations), but R and S are in G2 and T is in G1, so the proof of
+ * knowledge commits to 3 variables in G1 and 3 in G2 where AGHO commits
+ * to 4 in G1 and 2 in G2. As G2 elements are the larger ones on the curve
+ * of GenerateSharedParams, the proof is not smaller, see SPSProofSize; the
+ * scheme is an alternative to compare against, not an improvement.
+ */
+
+package ocert
+
+import (
+    "io"
+    "github.com/Nik-U/pbc"
+)
+
+type DualAGHOScheme struct {
+}
+
+func (scheme *DualAGHOScheme) Name() string {
+    return SPSSchemeDualAGHO
+}
+
+/*
+ * VK = (U, V, W1, W2, W3, Z) in G1 * G1 * G2^3 * G1
+ * SK = (u, v, w1, w2, w3, z) where u, v, w1, w2, w3, z is randomly picked from
+ * group of units modulo p
+ * s.t. U = u * g1, V = v * g1, W1 = w1 * g2, W2 = w2 * g2, W3 = w3 * g2 and
+ * Z = z * g1
+ */
+func (scheme *DualAGHOScheme) KeyGen(sharedParams *SharedParams, rand io.Reader) (*SVerificationKey, *SSigningKey) {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    g2 := pairing.NewG2().SetBytes(sharedParams.G2)
+
+    VK := new(SVerificationKey)
+    SK := new(SSigningKey)
+    VK.Scheme = SPSSchemeDualAGHO
+    SK.Scheme = SPSSchemeDualAGHO
+
+    u := randZr(pairing, rand)
+    v := randZr(pairing, rand)
+    w1 := randZr(pairing, rand)
+    w2 := randZr(pairing, rand)
+    w3 := randZr(pairing, rand)
+    z := randZr(pairing, rand)
+
+    SK.U = u.Bytes()
+    SK.V = v.Bytes()
+    SK.W1 = w1.Bytes()
+    SK.W2 = w2.Bytes()
+    SK.W3 = w3.Bytes()
+    SK.Z = z.Bytes()
+
+    VK.U = pairing.NewG1().MulZn(g1, u).Bytes()
+    VK.V = pairing.NewG1().MulZn(g1, v).Bytes()
+    VK.W1 = pairing.NewG2().MulZn(g2, w1).Bytes()
+    VK.W2 = pairing.NewG2().MulZn(g2, w2).Bytes()
+    VK.W3 = pairing.NewG2().MulZn(g2, w3).Bytes()
+    VK.Z = pairing.NewG1().MulZn(g1, z).Bytes()
+
+    return VK, SK
+}
+
+/*
+ * The ecert has format (R, S, T) in G2 * G2 * G1, where r is randomly picked
+ * from group of units modulo p, SKei = (u, v, w1, w2, w3, z), P = (C, D),
+ * epoch = E
+ * R = r * g2
+ * S = (z - r * v) * g2 + (-u) * PKc
+ * T = (1 / r) * (g1 + (-w1) * C + (-w2) * D + (-w3) * E)
+ */
+func (scheme *DualAGHOScheme) Sign(sharedParams *SharedParams, SKei *SSigningKey, P *Pseudonym, PKc *ClientPublicKey, epoch *Epoch, rand io.Reader) *Ecert {
+    ecert := new(Ecert)
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    g2 := pairing.NewG2().SetBytes(sharedParams.G2)
+
+    u := pairing.NewZr().SetBytes(SKei.U)
+    v := pairing.NewZr().SetBytes(SKei.V)
+    w1 := pairing.NewZr().SetBytes(SKei.W1)
+    w2 := pairing.NewZr().SetBytes(SKei.W2)
+    z := pairing.NewZr().SetBytes(SKei.Z)
+
+    C := pairing.NewG1().SetBytes(P.C)
+    D := pairing.NewG1().SetBytes(P.D)
+
+    N := pairing.NewG2().SetBytes(PKc.PK)
+
+    // Generate R
+    r := randZr(pairing, rand)
+    R := pairing.NewG2().MulZn(g2, r)
+    ecert.R = R.Bytes()
+
+    // Generate S
+    s0 := pairing.NewZr().Mul(r, v)
+    s0.Sub(z, s0)
+    S0 := pairing.NewG2().MulZn(g2, s0)
+
+    negU := pairing.NewZr().Neg(u)
+    S1 := pairing.NewG2().MulZn(N, negU)
+
+    S := pairing.NewG2().Add(S0, S1)
+    ecert.S = S.Bytes()
+
+    // Generate T
+    negW1 := pairing.NewZr().Neg(w1)
+    T0 := pairing.NewG1().MulZn(C, negW1)
+
+    negW2 := pairing.NewZr().Neg(w2)
+    T1 := pairing.NewG1().MulZn(D, negW2)
+
+    T := pairing.NewG1().Add(g1, T0)
+    T.Add(T, T1)
+
+    if epoch != nil {
+        w3 := pairing.NewZr().SetBytes(SKei.W3)
+        negW3 := pairing.NewZr().Neg(w3)
+        T2 := pairing.NewG1().MulZn(pairing.NewG1().SetBytes(epoch.E), negW3)
+        T.Add(T, T2)
+    }
+
+    invR := pairing.NewZr().Invert(r)
+    T.MulZn(T, invR)
+    ecert.T = T.Bytes()
+
+    return ecert
+}
+
+/*
+ * VKei = (U, V, W1, W2, W3, Z), P = (C, D), epoch = E, ecert = (R, S, T), and
+ * to verify, test
+ * e(V, R) * e(g1, S) * e(U, PKc) = e(Z, g2) and
+ * e(T, R) * e(C, W1) * e(D, W2) * e(E, W3) = e(g1, g2), where e is the
+ * pairing operation
+ */
+func (scheme *DualAGHOScheme) Verify(sharedParams *SharedParams, VKei *SVerificationKey, P *Pseudonym, PKc *ClientPublicKey, epoch *Epoch, ecert *Ecert) bool {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g1 := pairing.NewG1().SetBytes(sharedParams.G1)
+    g2 := pairing.NewG2().SetBytes(sharedParams.G2)
+
+    U := pairing.NewG1().SetBytes(VKei.U)
+    V := pairing.NewG1().SetBytes(VKei.V)
+    W1 := pairing.NewG2().SetBytes(VKei.W1)
+    W2 := pairing.NewG2().SetBytes(VKei.W2)
+    Z := pairing.NewG1().SetBytes(VKei.Z)
+
+    R := pairing.NewG2().SetBytes(ecert.R)
+    S := pairing.NewG2().SetBytes(ecert.S)
+    T := pairing.NewG1().SetBytes(ecert.T)
+
+    C := pairing.NewG1().SetBytes(P.C)
+    D := pairing.NewG1().SetBytes(P.D)
+
+    N := pairing.NewG2().SetBytes(PKc.PK)
+
+    // Verify e1 * e2 * e3 = e4
+    e1 := pairing.NewGT().Pair(V, R)
+    e2 := pairing.NewGT().Pair(g1, S)
+    e3 := pairing.NewGT().Pair(U, N)
+    e4 := pairing.NewGT().Pair(Z, g2)
+
+    LHS1 := pairing.NewGT().Mul(e1, e2)
+    LHS1.Mul(LHS1, e3)
+
+    if !LHS1.Equals(e4) {
+        return false
+    }
+
+    // Verify e5 * e6 * e7 = e8
+    e5 := pairing.NewGT().Pair(T, R)
+    e6 := pairing.NewGT().Pair(C, W1)
+    e7 := pairing.NewGT().Pair(D, W2)
+    e8 := pairing.NewGT().Pair(g1, g2)
+
+    LHS2 := pairing.NewGT().Mul(e5, e6)
+    LHS2.Mul(LHS2, e7)
+
+    if epoch != nil {
+        W3 := pairing.NewG2().SetBytes(VKei.W3)
+        eE := pairing.NewGT().Pair(pairing.NewG1().SetBytes(epoch.E), W3)
+        LHS2.Mul(LHS2, eE)
+    }
+
+    if !LHS2.Equals(e8) {
+        return false
+    }
+
+    return true
+}
+
+/*
+ * e(V, R) * e(g1, S) * e(U, PKc) = e(Z, g2)
+ * e(T, R) * e(C, W1) * e(D, W2) = e(g1, g2) / e(E, W3)
+ */
+func (scheme *DualAGHOScheme) Equations() []SPSEquation {
+    return []SPSEquation{
+        SPSEquation{
+            Pairings: []SPSPairing{
+                SPSPairing{"V", false, "R", true},
+                SPSPairing{"g1", false, "S", true},
+                SPSPairing{"U", false, "PKc", true},
+            },
+            Target: "e(Z, g2)",
+        },
+        SPSEquation{
+            Pairings: []SPSPairing{
+                SPSPairing{"T", true, "R", true},
+                SPSPairing{"C", true, "W1", false},
+                SPSPairing{"D", true, "W2", false},
+            },
+            Target: "e(g1, g2) / e(E, W3)",
+        },
+    }
+}
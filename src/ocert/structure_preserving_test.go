@@ -0,0 +1,112 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "testing"
+    "github.com/Nik-U/pbc"
+)
+
+var spsSchemes = []SPSScheme{new(AGHOScheme), new(DualAGHOScheme)}
+
+/*
+ * A random pseudonym and client public key to sign
+ */
+func newTestSPSMessage(pairing *pbc.Pairing) (*Pseudonym, *ClientPublicKey) {
+    P := new(Pseudonym)
+    P.C = pairing.NewG1().Rand().Bytes()
+    P.D = pairing.NewG1().Rand().Bytes()
+    PKc := new(ClientPublicKey)
+    PKc.PK = pairing.NewG2().Rand().Bytes()
+    return P, PKc
+}
+
+/*
+ * Sign and verify with each scheme, and reject the ecert when any signed
+ * message changes
+ */
+func TestSPSScheme(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+
+    for _, scheme := range spsSchemes {
+        t.Run(scheme.Name(), func(t *testing.T) {
+            VK, SK := scheme.KeyGen(sharedParams, nil)
+            P, PKc := newTestSPSMessage(pairing)
+            epoch := NewEpoch(sharedParams, 1)
+            ecert := scheme.Sign(sharedParams, SK, P, PKc, epoch, nil)
+            if !scheme.Verify(sharedParams, VK, P, PKc, epoch, ecert) {
+                t.Fatal("cannot verify an ecert")
+            }
+
+            modified := []struct {
+                name string
+                P *Pseudonym
+                PKc *ClientPublicKey
+                epoch *Epoch
+            }{
+                {"C", &Pseudonym{C: pairing.NewG1().Rand().Bytes(), D: P.D}, PKc, epoch},
+                {"D", &Pseudonym{C: P.C, D: pairing.NewG1().Rand().Bytes()}, PKc, epoch},
+                {"PKc", P, &ClientPublicKey{PK: pairing.NewG2().Rand().Bytes()}, epoch},
+                {"Epoch", P, PKc, NewEpoch(sharedParams, 0)},
+            }
+            for _, tc := range modified {
+                if scheme.Verify(sharedParams, VK, tc.P, tc.PKc, tc.epoch, ecert) {
+                    t.Errorf("accepts an ecert with another %s", tc.name)
+                }
+            }
+        })
+    }
+}
+
+/*
+ * Compare the schemes, and log the size of the proof of knowledge for an
+ * ecert of each
+ */
+func BenchmarkSPSScheme(b *testing.B) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    P, PKc := newTestSPSMessage(pairing)
+    epoch := NewEpoch(sharedParams, 0)
+
+    for _, scheme := range spsSchemes {
+        VK, SK := scheme.KeyGen(sharedParams, nil)
+        ecert := scheme.Sign(sharedParams, SK, P, PKc, epoch, nil)
+        size, err := SPSProofSize(sharedParams, scheme, nil)
+        if err != nil {
+            b.Fatal(err)
+        }
+        b.Logf("%s proof size: %d bytes", scheme.Name(), size)
+
+        b.Run(scheme.Name() + "/KeyGen", func(b *testing.B) {
+            for i := 0; i < b.N; i++ {
+                scheme.KeyGen(sharedParams, nil)
+            }
+        })
+        b.Run(scheme.Name() + "/Sign", func(b *testing.B) {
+            for i := 0; i < b.N; i++ {
+                scheme.Sign(sharedParams, SK, P, PKc, epoch, nil)
+            }
+        })
+        b.Run(scheme.Name() + "/Verify", func(b *testing.B) {
+            for i := 0; i < b.N; i++ {
+                scheme.Verify(sharedParams, VK, P, PKc, epoch, ecert)
+            }
+        })
+    }
+}
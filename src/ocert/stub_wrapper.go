@@ -19,18 +19,43 @@
 /*
  * A wrapper of shim.ChaincodeStubInterface, so we
  * can test part of chaincode locally, without starting
- * the whole Hyperledger Fabric network
+ * the whole Hyperledger Fabric network. It is the part of
+ * the stub used by the scheme, every method has the same
+ * signature as in shim.ChaincodeStubInterface, so a chaincode
+ * stub is a Wrapper. MemoryStub is an in-memory Wrapper.
  */
 
 package ocert
 
 import (
     "fmt"
+    "github.com/golang/protobuf/ptypes/timestamp"
+    "github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
 type Wrapper interface {
+    // World state
     GetState(key string) ([]byte, error)
     PutState(key string, value []byte) error
+    DelState(key string) error
+
+    // Private data collections
+    GetPrivateData(collection, key string) ([]byte, error)
+    PutPrivateData(collection string, key string, value []byte) error
+    DelPrivateData(collection, key string) error
+
+    // Range queries over simple keys, and over composite keys by prefix
+    GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error)
+    GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error)
+    CreateCompositeKey(objectType string, attributes []string) (string, error)
+    SplitCompositeKey(compositeKey string) (string, []string, error)
+
+    // The transaction
+    GetTxID() string
+    GetTxTimestamp() (*timestamp.Timestamp, error)
+    GetCreator() ([]byte, error)
+    GetTransient() (map[string][]byte, error)
+    SetEvent(name string, payload []byte) error
 }
 
 func Put(stub Wrapper, args [][]byte) ([]byte, error) {
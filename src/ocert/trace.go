@@ -0,0 +1,238 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Tracing of the ocerts of a client. Every versioned ocert issued by
+ * GenOCert is recorded with its pseudonym in the ocert registry on the
+ * ledger, under a composite key of the issuing transaction, so concurrent
+ * GenOCert transactions write different keys and do not conflict. The
+ * auditor decrypts all pseudonyms in the registry, in
+ * parallel, and finds the serials of the ocerts of a client. Tracing
+ * works on the ledger (TraceOCerts chaincode function) or offline on an
+ * exported ledger snapshot (LoadOCertRegistry and Trace).
+ */
+
+package ocert
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "runtime"
+    "sort"
+    "strings"
+    "sync"
+)
+
+const OCertRegistryObjectType = "ocert"
+
+/*
+ * Ledger key of the auditor key version the registry is being re-encrypted
+ * to, set by RotateAuditorKey and removed by ReEncryptOCertRegistry once
+ * every record is under it
+ */
+const OCertRegistryReEncryptionKey = "ocert_registry_reencrypt"
+
+func ocertRecordKey(stub Wrapper, txID string) (string, error) {
+    return stub.CreateCompositeKey(OCertRegistryObjectType, []string{txID})
+}
+
+/*
+ * Record an ocert issued by the current transaction in the registry, P is
+ * encrypted under the current auditor key
+ */
+func registerOCert(stub Wrapper, serial []byte, P *Pseudonym, notBefore int64) error {
+    version, err := getAuditorKeyVersion(stub)
+    if err != nil {
+        return err
+    }
+    record := new(OCertRecord)
+    record.TxID = stub.GetTxID()
+    record.KeyVersion = version
+    record.Serial = serial
+    record.P = P
+    record.NotBefore = notBefore
+    return putOCertRecord(stub, record)
+}
+
+func putOCertRecord(stub Wrapper, record *OCertRecord) error {
+    key, err := ocertRecordKey(stub, record.TxID)
+    if err != nil {
+        return err
+    }
+    value, err := record.Bytes()
+    if err != nil {
+        return err
+    }
+    return stub.PutState(key, value)
+}
+
+/*
+ * Issuance order: by issuance time, and by transaction ID within a second
+ */
+func sortOCertRecords(records []*OCertRecord) {
+    sort.Slice(records, func(i, j int) bool {
+        if records[i].NotBefore != records[j].NotBefore {
+            return records[i].NotBefore < records[j].NotBefore
+        }
+        return records[i].TxID < records[j].TxID
+    })
+}
+
+/*
+ * Read the whole ocert registry from the ledger, in the order of issuance
+ */
+func GetOCertRegistry(stub Wrapper) ([]*OCertRecord, error) {
+    iterator, err := stub.GetStateByPartialCompositeKey(OCertRegistryObjectType, []string{})
+    if err != nil {
+        return nil, err
+    }
+    defer iterator.Close()
+
+    records := make([]*OCertRecord, 0)
+    for iterator.HasNext() {
+        kv, err := iterator.Next()
+        if err != nil {
+            return nil, err
+        }
+        record := new(OCertRecord)
+        err = record.SetBytes(kv.Value)
+        if err != nil {
+            return nil, fmt.Errorf("Invalid registry entry %q: %s", kv.Key, err)
+        }
+        records = append(records, record)
+    }
+    sortOCertRecords(records)
+    return records, nil
+}
+
+/*
+ * Read the ocert registry from a ledger snapshot, a map from ledger keys
+ * to values. Other keys in the snapshot are ignored. A snapshot taken
+ * while the registry is re-encrypted to a new auditor key is refused.
+ */
+func LoadOCertRegistry(snapshot map[string][]byte) ([]*OCertRecord, error) {
+    if value, ok := snapshot[OCertRegistryReEncryptionKey]; ok {
+        return nil, fmt.Errorf("The ocert registry is being re-encrypted to auditor key version %s", value)
+    }
+    prefix := compositeKeyNamespace + OCertRegistryObjectType + compositeKeySeparator
+    records := make([]*OCertRecord, 0)
+    for key, value := range snapshot {
+        if !strings.HasPrefix(key, prefix) {
+            continue
+        }
+        record := new(OCertRecord)
+        err := record.SetBytes(value)
+        if err != nil {
+            return nil, fmt.Errorf("Invalid registry entry %q: %s", key, err)
+        }
+        records = append(records, record)
+    }
+    sortOCertRecords(records)
+    return records, nil
+}
+
+/*
+ * Decrypt the pseudonyms of all records with EDec on all CPUs and return
+ * the serials of the ocerts of target, in the order of the records
+ */
+func Trace(sharedParams *SharedParams, SKa *AuditorSecretKey, target *ClientID, records []*OCertRecord) [][]byte {
+    matches := make([]bool, len(records))
+
+    jobs := make(chan int)
+    var wg sync.WaitGroup
+    for w := 0; w < runtime.NumCPU(); w++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for i := range jobs {
+                id := EDec(sharedParams, SKa, records[i].P)
+                matches[i] = bytes.Equal(id.ID, target.ID)
+            }
+        }()
+    }
+    for i := range records {
+        jobs <- i
+    }
+    close(jobs)
+    wg.Wait()
+
+    serials := make([][]byte, 0)
+    for i, match := range matches {
+        if match {
+            serials = append(serials, records[i].Serial)
+        }
+    }
+    return serials
+}
+
+/*
+ * TraceOCerts is the chaincode function that traces the ocerts of the
+ * client with the given encoded ClientID. Only the approvers of the
+ * de-anonymization policy can trace, and only on peers of the auditor
+ * collection, which holds the auditor secret key. The trace is recorded
+ * there as an AuditRecord. It returns the JSON encoded list of serials.
+ */
+func TraceOCerts(stub Wrapper, args [][]byte) ([]byte, error) {
+    if len(args) != 1 {
+        return nil, fmt.Errorf("Incorrect arguments. Expecting a client id")
+    }
+    policy, err := getDeanonPolicy(stub)
+    if err != nil {
+        return nil, err
+    }
+    caller, err := requireApprover(stub, policy)
+    if err != nil {
+        return nil, err
+    }
+    SKa, err := auditorSecretKey(stub)
+    if err != nil {
+        return nil, err
+    }
+
+    pending, err := stub.GetState(OCertRegistryReEncryptionKey)
+    if err != nil {
+        return nil, err
+    }
+    if pending != nil {
+        return nil, fmt.Errorf("The ocert registry is being re-encrypted to auditor key version %s", pending)
+    }
+
+    target := new(ClientID)
+    err = target.SetBytes(args[0])
+    if err != nil {
+        return nil, err
+    }
+    records, err := GetOCertRegistry(stub)
+    if err != nil {
+        return nil, err
+    }
+
+    serials := Trace(sharedParams, SKa, target, records)
+    fmt.Printf("[Ocert Scheme] [TraceOCerts] %d of %d ocerts\n", len(serials), len(records))
+
+    enrollmentID, err := LookupClientID(stub, target)
+    if err != nil {
+        return nil, err
+    }
+    err = putAuditRecord(stub, &AuditRecord{Kind: "trace", ClientID: target.ID, EnrollmentID: enrollmentID, Serials: serials, Caller: caller})
+    if err != nil {
+        return nil, err
+    }
+    return json.Marshal(serials)
+}
@@ -0,0 +1,93 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "bytes"
+    "math/big"
+    "testing"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * Register ocerts of two clients, trace one of them from the ledger and
+ * from a snapshot of it
+ */
+func TestTrace(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    PKa, SKa := EKeyGen(sharedParams, nil)
+
+    target := new(ClientID)
+    target.ID = pairing.NewG1().Rand().Bytes()
+    other := new(ClientID)
+    other.ID = pairing.NewG1().Rand().Bytes()
+
+    // Ocerts 2, 5 and 6 belong to the target, each is issued by its own
+    // transaction
+    stub := NewMemoryStub()
+    owners := []*ClientID{other, target, other, other, target, target, other}
+    for i, owner := range owners {
+        stub.NextTx()
+        P, _ := ERerand(sharedParams, PKa, EEnc(sharedParams, PKa, owner, nil), nil)
+        if err := registerOCert(stub, big.NewInt(int64(i + 1)).Bytes(), P, int64(i)); err != nil {
+            t.Fatal(err)
+        }
+    }
+    stub.NextTx()
+
+    records, err := GetOCertRegistry(stub)
+    if err != nil {
+        t.Fatal(err)
+    }
+    snapshotRecords, err := LoadOCertRegistry(stub.State)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    expected := [][]byte{big.NewInt(2).Bytes(), big.NewInt(5).Bytes(), big.NewInt(6).Bytes()}
+    registries := []struct {
+        name string
+        records []*OCertRecord
+    }{
+        {"Ledger", records},
+        {"Snapshot", snapshotRecords},
+    }
+    for _, tc := range registries {
+        t.Run(tc.name, func(t *testing.T) {
+            if len(tc.records) != len(owners) {
+                t.Fatalf("got %d records, want %d", len(tc.records), len(owners))
+            }
+            serials := Trace(sharedParams, SKa, target, tc.records)
+            if len(serials) != len(expected) {
+                t.Fatalf("traced serials %v", serials)
+            }
+            for i := range serials {
+                if !bytes.Equal(serials[i], expected[i]) {
+                    t.Errorf("traced serials %v", serials)
+                }
+            }
+        })
+    }
+
+    // A snapshot taken while the registry is re-encrypted mixes keys
+    stub.State[OCertRegistryReEncryptionKey] = []byte("1")
+    if _, err := LoadOCertRegistry(stub.State); err == nil {
+        t.Error("loads a registry that is being re-encrypted")
+    }
+}
@@ -0,0 +1,101 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+ * Sensitive requests in the transient map. Chaincode args are recorded in
+ * the proposal and, for invokes, in the block, where every member of the
+ * channel can read them. The requests of GenECert, GenOCert and
+ * ReissueECert link a client to its public key and pseudonyms, so they are
+ * passed in the transient map under TransientRequestKey, which is not
+ * recorded, and the args stay empty. The partial decryptions of a
+ * threshold opening reveal the opened id to anyone who combines them, and
+ * are passed under TransientPartialsKey.
+ */
+
+package ocert
+
+import (
+    "encoding/json"
+    "fmt"
+)
+
+const TransientRequestKey = "request"
+const TransientPartialsKey = "partials"
+
+/*
+ * The transient map for an encoded request. Encoded with json.Marshal it
+ * is the --transient argument of the peer CLI.
+ */
+func TransientRequest(request []byte) map[string][]byte {
+    return map[string][]byte{TransientRequestKey: request}
+}
+
+/*
+ * The transient map for the partial decryptions of a threshold opening,
+ * see OpenDeanonymization
+ */
+func TransientPartials(partials []*PartialDecryption) (map[string][]byte, error) {
+    value, err := json.Marshal(partials)
+    if err != nil {
+        return nil, err
+    }
+    return map[string][]byte{TransientPartialsKey: value}, nil
+}
+
+/*
+ * Read the partial decryptions from the transient map, nil if there are
+ * none
+ */
+func transientPartials(stub Wrapper) ([]*PartialDecryption, error) {
+    transient, err := stub.GetTransient()
+    if err != nil {
+        return nil, err
+    }
+    value, ok := transient[TransientPartialsKey]
+    if !ok {
+        return nil, nil
+    }
+    partials := make([]*PartialDecryption, 0)
+    err = json.Unmarshal(value, &partials)
+    if err != nil {
+        return nil, err
+    }
+    if len(partials) == 0 {
+        return nil, fmt.Errorf("Transient field %s has no partial decryptions", TransientPartialsKey)
+    }
+    return partials, nil
+}
+
+/*
+ * Read the encoded request of a transaction from the transient map. A
+ * request in args is refused, it would be recorded on the ledger.
+ */
+func transientRequest(stub Wrapper, args [][]byte) ([]byte, error) {
+    if len(args) != 0 {
+        return nil, fmt.Errorf("Incorrect arguments. The request must be passed in the transient map")
+    }
+    transient, err := stub.GetTransient()
+    if err != nil {
+        return nil, err
+    }
+    request, ok := transient[TransientRequestKey]
+    if !ok || len(request) == 0 {
+        return nil, fmt.Errorf("Transient field not found: %s", TransientRequestKey)
+    }
+    return request, nil
+}
@@ -0,0 +1,87 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "testing"
+    "github.com/Nik-U/pbc"
+)
+
+/*
+ * GenECert takes its request from the transient map only
+ */
+func TestTransientRequest(t *testing.T) {
+    stub := NewMemoryStub()
+    if _, err := Setup(stub, [][]byte{[]byte(""), nil}); err != nil {
+        t.Fatal(err)
+    }
+
+    stub.Creator = newTestCreator("Org1MSP", "alice")
+    request := new(GenECertRequest)
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    request.PKc = pairing.NewG2().Rand().Bytes()
+    requestBytes, err := request.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if _, err := GenECert(stub, [][]byte{requestBytes}); err == nil {
+        t.Error("accepts a request in the arguments")
+    }
+    if _, err := GenECert(stub, nil); err == nil {
+        t.Error("accepts a proposal without a transient request")
+    }
+
+    stub.Transient = TransientRequest(requestBytes)
+    if _, err := invokeTx(t, stub, 1, GenECert, nil); err != nil {
+        t.Error(err)
+    }
+}
+
+/*
+ * The partial decryptions of a threshold opening round trip through the
+ * transient map, and an empty list is refused
+ */
+func TestTransientPartials(t *testing.T) {
+    stub := NewMemoryStub()
+    if partials, err := transientPartials(stub); err != nil || partials != nil {
+        t.Errorf("partials %v without a transient map: %v", partials, err)
+    }
+
+    partials := []*PartialDecryption{{Index: 1, XC: []byte("x1C")}, {Index: 3, XC: []byte("x3C")}}
+    transient, err := TransientPartials(partials)
+    if err != nil {
+        t.Fatal(err)
+    }
+    stub.Transient = transient
+    read, err := transientPartials(stub)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(read) != 2 || read[1].Index != 3 || string(read[1].XC) != "x3C" {
+        t.Errorf("read partials %v", read)
+    }
+
+    stub.Transient, err = TransientPartials(nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := transientPartials(stub); err == nil {
+        t.Error("accepts an empty list of partial decryptions")
+    }
+}
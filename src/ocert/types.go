@@ -97,16 +97,269 @@ func (KPa *AuditorKeypair) SetBytes(msg []byte) error {
     return err
 }
 
+/*
+ * The auditor keypair encrypted to the delivery key of the auditor, see
+ * key_delivery.go
+ */
+type SealedAuditorKeypair struct {
+    Ephemeral  []byte
+    Nonce      []byte
+    Ciphertext []byte
+}
+
+func (sealed *SealedAuditorKeypair) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(sealed)
+    return msg, err
+}
+
+func (sealed *SealedAuditorKeypair) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, sealed)
+    return err
+}
+
+/*
+ * Proof that a pseudonym was re-encrypted and rerandomized under a new
+ * auditor key, see VerifyReEncryption. KeyResponse is the response for
+ * the token delta and RerandResponse the one for the rerandomization s.
+ */
+type ReEncryptionProof struct {
+    Challenge      []byte
+    KeyResponse    []byte
+    RerandResponse []byte
+}
+
+func (proof *ReEncryptionProof) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(proof)
+    return msg, err
+}
+
+func (proof *ReEncryptionProof) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, proof)
+    return err
+}
+
+/*
+ * The reply of Setup, RotateAuditorKey and GetAuditorKeypair. Sealed is
+ * nil without a delivery key, or for a threshold key, which has no
+ * secret key.
+ */
+type AuditorKeyReply struct {
+    PK     []byte
+    Sealed *SealedAuditorKeypair
+}
+
+func (reply *AuditorKeyReply) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(reply)
+    return msg, err
+}
+
+func (reply *AuditorKeyReply) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, reply)
+    return err
+}
+
+/*
+ * The reply of ReEncryptOCertRegistry: the number of records of the page
+ * re-encrypted under auditor key version Version, and whether the whole
+ * registry is under that version now
+ */
+type ReEncryptionProgress struct {
+    Version     int
+    ReEncrypted int
+    Done        bool
+}
+
+func (progress *ReEncryptionProgress) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(progress)
+    return msg, err
+}
+
+func (progress *ReEncryptionProgress) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, progress)
+    return err
+}
+
+/*
+ * Re-encryption token from auditor key version From to version To, that
+ * is x_To - x_From. Anyone holding it and one of the two secret keys can
+ * compute the other, so it must be kept like a secret key.
+ */
+type ReKeyToken struct {
+    From  int
+    To    int
+    Delta []byte
+}
+
+func (token *ReKeyToken) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(token)
+    return msg, err
+}
+
+func (token *ReKeyToken) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, token)
+    return err
+}
+
+/*
+ * The public part of one dealer's contribution to the distributed
+ * generation of a threshold auditor key. Commitments are a_k * g1 for
+ * the coefficients a_0 ... a_{t-1} of the dealer's secret polynomial.
+ */
+type AuditorDeal struct {
+    Dealer      int
+    Commitments [][]byte
+}
+
+func (deal *AuditorDeal) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(deal)
+    return msg, err
+}
+
+func (deal *AuditorDeal) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, deal)
+    return err
+}
+
+/*
+ * The dealer's polynomial evaluated at the recipient's index. It must
+ * only be sent to the recipient, over a private channel.
+ */
+type AuditorDealShare struct {
+    Dealer    int
+    Recipient int
+    Share     []byte
+}
+
+func (share *AuditorDealShare) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(share)
+    return msg, err
+}
+
+func (share *AuditorDealShare) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, share)
+    return err
+}
+
+/*
+ * The auditor public key shared t-of-n among auditors 1 ... N. PK is
+ * used exactly like AuditorPublicKey.PK, Commitments is the sum of all
+ * dealers' commitments and gives the verification key of every share.
+ */
+type ThresholdAuditorPublicKey struct {
+    Threshold   int
+    N           int
+    PK          []byte
+    Commitments [][]byte
+}
+
+func (TPKa *ThresholdAuditorPublicKey) AuditorPublicKey() *AuditorPublicKey {
+    PKa := new(AuditorPublicKey)
+    PKa.PK = TPKa.PK
+    return PKa
+}
+
+func (TPKa *ThresholdAuditorPublicKey) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(TPKa)
+    return msg, err
+}
+
+func (TPKa *ThresholdAuditorPublicKey) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, TPKa)
+    return err
+}
+
+/*
+ * The share of the threshold auditor secret key held by one auditor
+ */
+type AuditorKeyShare struct {
+    Index int
+    SK    []byte
+}
+
+func (share *AuditorKeyShare) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(share)
+    return msg, err
+}
+
+func (share *AuditorKeyShare) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, share)
+    return err
+}
+
+/*
+ * Non-interactive proof that log_g(X) = log_h(Y) (Chaum-Pedersen)
+ */
+type DLEQProof struct {
+    Challenge []byte
+    Response  []byte
+}
+
+/*
+ * Proof of the result of a plaintext equality test of P1 and P2. (C, D) is
+ * the blinded quotient z * (C1 - C2, D1 - D2) and XC = x * C, the pseudonyms
+ * encrypt the same id exactly if XC = D. Blinding proves that (C, D) was
+ * blinded correctly and Decryption that XC was computed with the auditor's
+ * key. The proof is nil if C1 = C2, where the result is public.
+ */
+type EqualityProof struct {
+    C          []byte
+    D          []byte
+    XC         []byte
+    Blinding   *DLEQProof
+    Decryption *DLEQProof
+}
+
+func (proof *EqualityProof) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(proof)
+    return msg, err
+}
+
+func (proof *EqualityProof) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, proof)
+    return err
+}
+
+/*
+ * The partial decryption of a pseudonym (C, D) by one auditor, that is
+ * x_i * C together with a proof that the auditor used its share x_i
+ */
+type PartialDecryption struct {
+    Index int
+    XC    []byte
+    Proof *DLEQProof
+}
+
+func (partial *PartialDecryption) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(partial)
+    return msg, err
+}
+
+func (partial *PartialDecryption) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, partial)
+    return err
+}
+
 /*****************************************************************/
 
 /*
  * The identity of a client
  */
-// We assume client id is an element from G1 for simplicity.
+// A client id is an element from G1. NewClientID hashes an enrollment
+// id to it.
 type ClientID struct {
     ID []byte
 }
 
+func (id *ClientID) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(id)
+    return msg, err
+}
+
+func (id *ClientID) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, id)
+    return err
+}
+
 /*
  * The public key of the client. This public key can be generated
  * by any scheme, but it should be an element in G2.
@@ -152,14 +405,19 @@ func (P *Pseudonym) Equals(P2 *Pseudonym) bool {
 
 /*
  * Based on structure-preserving scheme S. The verification key contains
- * 5 elements, U, V, W1, W2 and Z, where only U is an element in G1 and
- * the rest are elements in G2.
+ * 6 elements, U, V, W1, W2, W3 and Z. For AGHO only U is an element in G1
+ * and the rest are elements in G2, for Dual AGHO U, V and Z are in G1 and
+ * W1, W2 and W3 in G2. W1 and W2 are paired with the pseudonym (C, D), W3
+ * with the validity epoch and U with the client public key. Scheme names
+ * the SPSScheme that generated the key, an empty name means AGHO.
  */
 type SVerificationKey struct {
+    Scheme string
     U  []byte
     V  []byte
     W1 []byte
     W2 []byte
+    W3 []byte
     Z  []byte
 }
 
@@ -174,10 +432,12 @@ func (VK *SVerificationKey) SetBytes(msg []byte) error {
 }
 
 func (VK *SVerificationKey) Equals(VK2 *SVerificationKey) bool {
-    return  bytes.Equal(VK.U, VK2.U) &&
+    return  VK.Scheme == VK2.Scheme &&
+        bytes.Equal(VK.U, VK2.U) &&
         bytes.Equal(VK.V, VK2.V) &&
         bytes.Equal(VK.W1, VK2.W1) &&
         bytes.Equal(VK.W2, VK2.W2) &&
+        bytes.Equal(VK.W3, VK2.W3) &&
         bytes.Equal(VK.Z, VK2.Z)
 }
 
@@ -186,16 +446,50 @@ func (VK *SVerificationKey) Equals(VK2 *SVerificationKey) bool {
  * key.
  */
 type SSigningKey struct {
+    Scheme string
     U  []byte
     V  []byte
     W1 []byte
     W2 []byte
+    W3 []byte
     Z  []byte
 }
 
+func (SK *SSigningKey) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(SK)
+    return msg, err
+}
+
+func (SK *SSigningKey) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, SK)
+    return err
+}
+
+/*
+ * The validity epoch of an ecert. The issuer moves the epoch forward on
+ * the ledger, and an ecert is only accepted by GenOCert during the epoch
+ * it was signed for. E is the epoch number Epoch encoded as an element
+ * in G1, see NewEpoch.
+ */
+type Epoch struct {
+    Epoch uint64
+    E     []byte
+}
+
+func (epoch *Epoch) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(epoch)
+    return msg, err
+}
+
+func (epoch *Epoch) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, epoch)
+    return err
+}
+
 /*
  * Ecert is the signature generated by scheme S. It contains three elements
- * R, S and T, where R and S are in G1 and T is in G2.
+ * R, S and T, where R and S are in G1 and T is in G2. The dual AGHO scheme
+ * swaps the groups, so R and S are in G2 and T is in G1.
  */
 type Ecert struct {
     R []byte
@@ -1013,6 +1307,7 @@ type ProofConstants struct {
     Egz    []byte            // e(g1, Z)
     PKa    *AuditorPublicKey
     Egh    []byte            // e(G, H)
+    Epoch  []byte            // E, the current validity epoch
 }
 
 func (consts *ProofConstants) Print() {
@@ -1031,6 +1326,9 @@ func (consts *ProofConstants) Print() {
 
     fmt.Printf("\t[Egh]: ")
     fmt.Println(consts.Egh)
+
+    fmt.Printf("\t[Epoch]: ")
+    fmt.Println(consts.Epoch)
     fmt.Println("-------------------")
     fmt.Println("")
 }
@@ -1040,7 +1338,8 @@ func (consts *ProofConstants) Equals(consts2 *ProofConstants) bool {
         consts.PPrime.Equals(consts2.PPrime) &&
         bytes.Equal(consts.Egz, consts2.Egz) &&
         bytes.Equal(consts.PKa.PK, consts2.PKa.PK) &&
-        bytes.Equal(consts.Egh, consts2.Egh)
+        bytes.Equal(consts.Egh, consts2.Egh) &&
+        bytes.Equal(consts.Epoch, consts2.Epoch)
 }
 
 
@@ -1061,12 +1360,14 @@ func (consts *ProofConstants) Bytes() ([]byte, error) {
         Egz    []byte
         PKa    []byte
         Egh    []byte 
+        Epoch  []byte
     } {
         VKbytes,
         PPrimeBytes,
         consts.Egz,
         consts.PKa.PK,
         consts.Egh,
+        consts.Epoch,
     }
 
     msg, err := json.Marshal(template)
@@ -1080,6 +1381,7 @@ func (consts *ProofConstants) SetBytes(msg []byte) error {
         Egz    []byte
         PKa    []byte
         Egh    []byte 
+        Epoch  []byte
     })
 
     err := json.Unmarshal(msg, template)
@@ -1108,6 +1410,7 @@ func (consts *ProofConstants) SetBytes(msg []byte) error {
     consts.PKa = PKa
 
     consts.Egh = template.Egh
+    consts.Epoch = template.Epoch
 
     return nil
 }
@@ -1117,6 +1420,8 @@ func (consts *ProofConstants) SetBytes(msg []byte) error {
  * Request to and reply from main scheme (chaincode)
  */
 
+// The client id is derived from the enrollment certificate of the
+// caller, a request with IDc set is rejected.
 type GenECertRequest struct {
     IDc []byte
     PKc []byte
@@ -1132,9 +1437,22 @@ func (request *GenECertRequest) SetBytes(msg []byte) error {
     return err
 }
 
+/*
+ * The reply of GenECert. EncProof proves that P encrypts the client id of
+ * EnrollmentID under the auditor_pk on the ledger, see VerifyEncryption
+ * and NewClientID. The client checks that EnrollmentID is its own. VKVersion
+ * is the version of the verification key of the ecert. An ecert
+ * re-issued by ReissueECert has ReEncProof instead, which proves that P
+ * is the re-encryption of the old pseudonym, see VerifyReEncryption.
+ */
 type GenECertReply struct {
     P []byte
     Ecert []byte
+    Epoch []byte
+    EncProof *DLEQProof
+    ReEncProof *ReEncryptionProof
+    EnrollmentID string
+    VKVersion int
 }
 
 func (reply *GenECertReply) Bytes() ([]byte, error) {
@@ -1147,10 +1465,65 @@ func (reply *GenECertReply) SetBytes(msg []byte) error {
     return err
 }
 
+/*
+ * Request of the auditor to rotate its key. The auditor generates the new
+ * key and the token from the current key to it itself, with EKeyGen and
+ * EReKeyToken. SK is the new secret key, given only if the chaincode is
+ * to open pseudonyms with it; it is kept in the auditor collection.
+ */
+type RotateAuditorKeyRequest struct {
+    PK    []byte
+    Token *ReKeyToken
+    SK    []byte
+}
+
+func (request *RotateAuditorKeyRequest) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(request)
+    return msg, err
+}
+
+func (request *RotateAuditorKeyRequest) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, request)
+    return err
+}
+
+/*
+ * Request to re-issue an ecert after the auditor key was rotated. Ecert
+ * is the ecert on P and PKc for Epoch, the last epoch of an older auditor
+ * key version, signed with verification key version VKVersion.
+ */
+type ReissueECertRequest struct {
+    P         []byte
+    PKc       []byte
+    Ecert     []byte
+    Epoch     uint64
+    VKVersion int
+}
+
+func (request *ReissueECertRequest) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(request)
+    return msg, err
+}
+
+func (request *ReissueECertRequest) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, request)
+    return err
+}
+
+/*
+ * Version is the ocert format, see certificate.go. Version 0 is the legacy
+ * request, which is replied with a raw signature on PKc|P. Version
+ * OCertVersion is replied with a signed OCertBody, and version
+ * OCertVersionX509 with a DER encoded X.509 certificate. VKVersion is the
+ * version of the structure preserving verification key that signed the
+ * ecert the proof is about.
+ */
 type GenOCertRequest struct {
+    Version int
     PKc []byte
     P []byte
     Pi []byte
+    VKVersion int
 }
 
 func (request *GenOCertRequest) Bytes() ([]byte, error) {
@@ -1164,7 +1537,9 @@ func (request *GenOCertRequest) SetBytes(msg []byte) error {
 }
 
 type GenOCertReply struct {
+    Body []byte
     Sig []byte
+    Cert []byte
 }
 
 func (reply *GenOCertReply) Bytes() ([]byte, error) {
@@ -1177,16 +1552,297 @@ func (reply *GenOCertReply) SetBytes(msg []byte) error {
     return err
 }
 
-type RSAPK struct {
+/*
+ * The signed content of an ocert. NotBefore is the timestamp of the
+ * transaction that issued the ocert, and the ocert is valid until NotAfter.
+ * Both are in seconds since the Unix epoch. Issuer identifies the key that
+ * signed the ocert, and Serial is the serial number assigned by the issuer.
+ */
+type OCertBody struct {
+    Version   int
+    Serial    []byte
+    Issuer    string
+    NotBefore int64
+    NotAfter  int64
+    KeyUsage  []string
+    PKc       []byte
+    P         []byte
+}
+
+func (body *OCertBody) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(body)
+    return msg, err
+}
+
+func (body *OCertBody) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, body)
+    return err
+}
+
+/*
+ * The public key of the ocert issuer, stored on the ledger. Algorithm is
+ * the name of the OCertSigner, an empty name means RSA PKCS#1 v1.5. PK is
+ * the PKIX encoded key, or the element in G2 for BLS.
+ */
+type OCertPK struct {
+    Algorithm string
     PK []byte
 }
 
-func (pk *RSAPK) Bytes() ([]byte, error) {
+func (pk *OCertPK) Bytes() ([]byte, error) {
     msg, err := json.Marshal(pk)
     return msg, err
 }
 
-func (pk *RSAPK) SetBytes(msg []byte) error {
+func (pk *OCertPK) SetBytes(msg []byte) error {
     err := json.Unmarshal(msg, pk)
     return err
 }
+
+/*****************************************************************/
+/*
+ * De-anonymization workflow
+ */
+
+/*
+ * The identities allowed to approve de-anonymization requests and the
+ * number of approvals required to open a pseudonym. Approvers are
+ * identity IDs, see IdentityID.
+ */
+type DeanonPolicy struct {
+    Approvers []string
+    Required  int
+}
+
+func (policy *DeanonPolicy) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(policy)
+    return msg, err
+}
+
+func (policy *DeanonPolicy) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, policy)
+    return err
+}
+
+/*
+ * Request to open the pseudonym in an ocert (an encoded GenOCertReply)
+ * or a pseudonym given directly (an encoded Pseudonym). DeliveryKey is
+ * the PEM encoded ECDSA public key of the requester, or a certificate
+ * with one, the result is sealed to it.
+ */
+type DeanonRequest struct {
+    OCert       []byte
+    P           []byte
+    Reason      string
+    DeliveryKey []byte
+}
+
+func (request *DeanonRequest) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(request)
+    return msg, err
+}
+
+func (request *DeanonRequest) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, request)
+    return err
+}
+
+/*
+ * One step of the workflow, Time is the transaction timestamp in seconds
+ * since the Unix epoch
+ */
+type DeanonLogEntry struct {
+    Action   string
+    Identity string
+    Time     int64
+}
+
+/*
+ * A de-anonymization request as recorded on the ledger. Serial is the
+ * serial number of the ocert, if the request was filed for an ocert.
+ * The opened client id is not part of the record, it is only in the
+ * AuditRecord in the auditor collection.
+ */
+type DeanonRecord struct {
+    ID          uint64
+    Status      string
+    Requester   string
+    Reason      string
+    P           *Pseudonym
+    Serial      []byte
+    DeliveryKey []byte
+    Approvals   []string
+    Log         []DeanonLogEntry
+}
+
+func (record *DeanonRecord) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(record)
+    return msg, err
+}
+
+func (record *DeanonRecord) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, record)
+    return err
+}
+
+/*
+ * The opened client id. Proof shows that the auditor decrypted honestly,
+ * see VerifyDecryption. It is nil for a threshold key, where every
+ * partial decryption carries its own proof. EnrollmentID is resolved
+ * from the identity directory, it is empty for an unknown client id.
+ */
+type DeanonResult struct {
+    ID           []byte
+    EnrollmentID string
+    Proof        *DLEQProof
+}
+
+func (result *DeanonResult) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(result)
+    return msg, err
+}
+
+func (result *DeanonResult) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, result)
+    return err
+}
+
+/*
+ * The DeanonResult encrypted to the delivery key of the requester, see
+ * key_delivery.go
+ */
+type SealedDeanonResult struct {
+    Ephemeral  []byte
+    Nonce      []byte
+    Ciphertext []byte
+}
+
+func (sealed *SealedDeanonResult) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(sealed)
+    return msg, err
+}
+
+func (sealed *SealedDeanonResult) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, sealed)
+    return err
+}
+
+/*****************************************************************/
+/*
+ * The registry of issued ocerts on the ledger, used to trace the ocerts
+ * of a client. TxID is the transaction that issued the ocert and names
+ * the record, Serial the serial number of the ocert and NotBefore its
+ * issuance time.
+ */
+type OCertRecord struct {
+    TxID       string
+    Serial     []byte
+    P          *Pseudonym
+    NotBefore  int64
+    KeyVersion int
+}
+
+func (record *OCertRecord) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(record)
+    return msg, err
+}
+
+func (record *OCertRecord) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, record)
+    return err
+}
+
+/*
+ * A version of the structure preserving verification key on the ledger.
+ * NotAfter is 0 for the current version. Once the key is rotated, proofs
+ * under an old version are accepted until NotAfter, in seconds since the
+ * Unix epoch.
+ */
+type SVKVersion struct {
+    Version  int
+    VK       *SVerificationKey
+    NotAfter int64
+}
+
+func (version *SVKVersion) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(version)
+    return msg, err
+}
+
+func (version *SVKVersion) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, version)
+    return err
+}
+
+/*
+ * The reply of GetIssuerKeyStatus. Refresh tells the client to request a new
+ * ecert, because its ecert was signed with an old key version.
+ */
+type IssuerKeyStatus struct {
+    Current  int
+    Version  int
+    NotAfter int64
+    Refresh  bool
+}
+
+func (status *IssuerKeyStatus) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(status)
+    return msg, err
+}
+
+func (status *IssuerKeyStatus) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, status)
+    return err
+}
+
+/*****************************************************************/
+/*
+ * The payload of a chaincode event, see events.go. Serial is the serial of
+ * an issued or opened ocert, VKVersion the version of the issuer key that
+ * signed the ecert, Request the de-anonymization request that was opened
+ * and Timestamp the transaction time in seconds. Events carry no client
+ * ids, pseudonyms or keys.
+ */
+type OCertEvent struct {
+    Type      string
+    Serial    []byte `json:",omitempty"`
+    VKVersion int
+    Epoch     uint64
+    Request   uint64 `json:",omitempty"`
+    Timestamp int64
+}
+
+func (event *OCertEvent) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(event)
+    return msg, err
+}
+
+func (event *OCertEvent) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, event)
+    return err
+}
+
+/*
+ * An audit record of a decryption of client ids, kept in the auditor
+ * collection. Kind is "open" for OpenDeanonymization, with the opened
+ * request, or "trace" for TraceOCerts, with the serials found.
+ */
+type AuditRecord struct {
+    Kind         string
+    Request      uint64   `json:",omitempty"`
+    ClientID     []byte
+    EnrollmentID string
+    Serials      [][]byte `json:",omitempty"`
+    Caller       string
+    Time         int64
+}
+
+func (record *AuditRecord) Bytes() ([]byte, error) {
+    msg, err := json.Marshal(record)
+    return msg, err
+}
+
+func (record *AuditRecord) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, record)
+    return err
+}
@@ -0,0 +1,123 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "testing"
+)
+
+/*
+ * Two setups of the same witness give different proofs of knowledge
+ */
+func TestProofOfKnowledgeEquals(t *testing.T) {
+    f := newProofFixture()
+    pi := PSetup(f.sharedParams, f.vars, nil)
+    pi2 := pi
+    if !pi.Equals(pi2) {
+        t.Error("a proof of knowledge differs from itself")
+    }
+    pi3 := PSetup(f.sharedParams, f.vars, nil)
+    if pi.Equals(pi3) {
+        t.Error("two setups give equal proofs of knowledge")
+    }
+}
+
+/*
+ * Each serialized type decodes to an equal value
+ */
+func TestTypesRoundTrip(t *testing.T) {
+    f := newProofFixture()
+    pi := PSetup(f.sharedParams, f.vars, nil)
+
+    cases := []struct {
+        name string
+        roundTrip func() (bool, error)
+    }{
+        {"CommonReferenceString", func() (bool, error) {
+            data, err := pi.sigma.Bytes()
+            if err != nil {
+                return false, err
+            }
+            crs := new(CommonReferenceString)
+            if err := crs.SetBytes(data); err != nil {
+                return false, err
+            }
+            return pi.sigma.Equals(crs), nil
+        }},
+        {"ProofOfEquation", func() (bool, error) {
+            data, err := pi.Eq1.Bytes()
+            if err != nil {
+                return false, err
+            }
+            eq := new(ProofOfEquation)
+            if err := eq.SetBytes(data); err != nil {
+                return false, err
+            }
+            return pi.Eq1.Equals(eq), nil
+        }},
+        {"ProofOfKnowledge", func() (bool, error) {
+            data, err := pi.Bytes()
+            if err != nil {
+                return false, err
+            }
+            pi2 := new(ProofOfKnowledge)
+            if err := pi2.SetBytes(data); err != nil {
+                return false, err
+            }
+            return pi.Equals(pi2), nil
+        }},
+        {"ProofConstants", func() (bool, error) {
+            data, err := f.consts.Bytes()
+            if err != nil {
+                return false, err
+            }
+            consts := new(ProofConstants)
+            if err := consts.SetBytes(data); err != nil {
+                return false, err
+            }
+            return f.consts.Equals(consts), nil
+        }},
+    }
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            equal, err := tc.roundTrip()
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !equal {
+                t.Error("decoded value differs from the original")
+            }
+        })
+    }
+}
+
+/*
+ * Constants on the original pseudonym differ from those on the
+ * rerandomized one
+ */
+func TestProofConstantsEquals(t *testing.T) {
+    f := newProofFixture()
+    consts := *f.consts
+    if !f.consts.Equals(&consts) {
+        t.Error("copied constants differ")
+    }
+    consts.PPrime = f.vars.P
+    if f.consts.Equals(&consts) {
+        t.Error("constants on another pseudonym are equal")
+    }
+}
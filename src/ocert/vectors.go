@@ -0,0 +1,330 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+/*
+ * Known-answer test vectors. The randomness of every vector is read from
+ * NewPRFReader(seed, "ocert-test-vector", name), so the outputs are fully
+ * determined by the shared parameters, the seed and the inputs, and
+ * another implementation that draws its randomness from the same stream
+ * in the same order must compute the same bytes. Scalars and group
+ * elements are drawn as in randZr, randG1 and randG2.
+ *
+ * GenerateTestVectors writes the vectors, VerifyTestVectors replays them
+ * from their inputs and compares the outputs. See vector-tool/vectors.go.
+ */
+
+package ocert
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/json"
+    "fmt"
+    "io"
+    "github.com/Nik-U/pbc"
+)
+
+const TestVectorsVersion = 1
+
+type TestVectors struct {
+    Version      int
+    Seed         []byte
+    SharedParams *SharedParams
+    ElGamal      *ElGamalVector
+    SPS          []*SPSVector
+    GrothSahai   *GrothSahaiVector
+    OCert        *OCertVector
+}
+
+/*
+ * EKeyGen, EEncWithProof and ERerandWithProof of the client id ID
+ */
+type ElGamalVector struct {
+    ID          *ClientID
+    PKa         *AuditorPublicKey
+    SKa         *AuditorSecretKey
+    P           *Pseudonym
+    EncProof    *DLEQProof
+    PPrime      *Pseudonym
+    RPrime      []byte
+    RerandProof *DLEQProof
+}
+
+/*
+ * KeyGen and Sign of a structure-preserving scheme on P, PKc and Epoch
+ */
+type SPSVector struct {
+    Scheme string
+    P      *Pseudonym
+    PKc    *ClientPublicKey
+    Epoch  *Epoch
+    VK     *SVerificationKey
+    SK     *SSigningKey
+    Ecert  *Ecert
+}
+
+/*
+ * CreateCommonReferenceString with a random alpha, encoded by Bytes
+ */
+type GrothSahaiVector struct {
+    Alpha []byte
+    CRS   []byte
+}
+
+/*
+ * The GenOCert request of a client: ERerand of P, PSetup for the ecert of
+ * P and PKc = Xc * g2, and the result of PProve on the proof Pi
+ */
+type OCertVector struct {
+    Xc     []byte
+    PKc    *ClientPublicKey
+    PKa    *AuditorPublicKey
+    P      *Pseudonym
+    VK     *SVerificationKey
+    Ecert  *Ecert
+    Epoch  *Epoch
+    PPrime *Pseudonym
+    RPrime []byte
+    Pi     []byte
+    Valid  bool
+}
+
+func (vectors *TestVectors) Bytes() ([]byte, error) {
+    msg, err := json.MarshalIndent(vectors, "", "  ")
+    return msg, err
+}
+
+func (vectors *TestVectors) SetBytes(msg []byte) error {
+    err := json.Unmarshal(msg, vectors)
+    return err
+}
+
+func vectorRand(seed []byte, name string) io.Reader {
+    return NewPRFReader(seed, []byte("ocert-test-vector"), []byte(name))
+}
+
+/*
+ * Generate the vectors of all primitives for the shared parameters and
+ * the seed. The inputs are derived from fixed strings, the ElGamal
+ * pseudonym is signed by the SPS vectors and the AGHO ecert is proven in
+ * the ocert vector.
+ */
+func GenerateTestVectors(sharedParams *SharedParams, seed []byte) (*TestVectors, error) {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    g2 := pairing.NewG2().SetBytes(sharedParams.G2)
+
+    vectors := new(TestVectors)
+    vectors.Version = TestVectorsVersion
+    vectors.Seed = seed
+    vectors.SharedParams = sharedParams
+
+    vectors.ElGamal = new(ElGamalVector)
+    vectors.ElGamal.ID = NewClientID(sharedParams, "test-vector-client")
+    runElGamalVector(sharedParams, seed, vectors.ElGamal)
+
+    xc := pairing.NewZr().SetFromStringHash("test-vector-client-key", sha256.New())
+    PKc := new(ClientPublicKey)
+    PKc.PK = pairing.NewG2().MulZn(g2, xc).Bytes()
+    for _, name := range []string{SPSSchemeAGHO, SPSSchemeDualAGHO} {
+        v := new(SPSVector)
+        v.Scheme = name
+        v.P = vectors.ElGamal.P
+        v.PKc = PKc
+        v.Epoch = NewEpoch(sharedParams, 1)
+        err := runSPSVector(sharedParams, seed, v)
+        if err != nil {
+            return nil, err
+        }
+        vectors.SPS = append(vectors.SPS, v)
+    }
+
+    vectors.GrothSahai = new(GrothSahaiVector)
+    runGrothSahaiVector(sharedParams, seed, vectors.GrothSahai)
+
+    agho := vectors.SPS[0]
+    vectors.OCert = new(OCertVector)
+    vectors.OCert.Xc = xc.Bytes()
+    vectors.OCert.PKc = PKc
+    vectors.OCert.PKa = vectors.ElGamal.PKa
+    vectors.OCert.P = vectors.ElGamal.P
+    vectors.OCert.VK = agho.VK
+    vectors.OCert.Ecert = agho.Ecert
+    vectors.OCert.Epoch = agho.Epoch
+    err := runOCertVector(sharedParams, seed, vectors.OCert)
+    if err != nil {
+        return nil, err
+    }
+    if !vectors.OCert.Valid {
+        return nil, fmt.Errorf("The proof of the ocert vector does not verify")
+    }
+    return vectors, nil
+}
+
+/*
+ * Replay the vectors from their inputs and compare every output, and
+ * check the outputs with the verification functions of the package
+ */
+func VerifyTestVectors(vectors *TestVectors) error {
+    if vectors.Version != TestVectorsVersion {
+        return fmt.Errorf("Unknown test vectors version %d", vectors.Version)
+    }
+    if vectors.SharedParams == nil || vectors.ElGamal == nil || vectors.GrothSahai == nil || vectors.OCert == nil {
+        return fmt.Errorf("Incomplete test vectors")
+    }
+    sharedParams := vectors.SharedParams
+    seed := vectors.Seed
+
+    eg := new(ElGamalVector)
+    eg.ID = vectors.ElGamal.ID
+    runElGamalVector(sharedParams, seed, eg)
+    err := compareVector("ElGamal", vectors.ElGamal, eg)
+    if err != nil {
+        return err
+    }
+    if !bytes.Equal(EDec(sharedParams, eg.SKa, eg.PPrime).ID, eg.ID.ID) {
+        return fmt.Errorf("ElGamal: PPrime does not decrypt to ID")
+    }
+    if !VerifyEncryption(sharedParams, eg.PKa, eg.P, eg.ID, eg.EncProof) {
+        return fmt.Errorf("ElGamal: invalid encryption proof")
+    }
+    if !ERerandVerifyPublic(sharedParams, eg.PKa, eg.P, eg.PPrime, eg.RerandProof) {
+        return fmt.Errorf("ElGamal: invalid rerandomization proof")
+    }
+
+    for _, expected := range vectors.SPS {
+        v := new(SPSVector)
+        v.Scheme = expected.Scheme
+        v.P = expected.P
+        v.PKc = expected.PKc
+        v.Epoch = expected.Epoch
+        err = runSPSVector(sharedParams, seed, v)
+        if err != nil {
+            return err
+        }
+        err = compareVector("SPS " + v.Scheme, expected, v)
+        if err != nil {
+            return err
+        }
+        scheme, _ := GetSPSScheme(v.Scheme)
+        if !scheme.Verify(sharedParams, v.VK, v.P, v.PKc, v.Epoch, v.Ecert) {
+            return fmt.Errorf("SPS %s: invalid ecert", v.Scheme)
+        }
+    }
+
+    gs := new(GrothSahaiVector)
+    runGrothSahaiVector(sharedParams, seed, gs)
+    err = compareVector("GrothSahai", vectors.GrothSahai, gs)
+    if err != nil {
+        return err
+    }
+
+    oc := new(OCertVector)
+    *oc = *vectors.OCert
+    oc.PPrime, oc.RPrime, oc.Pi, oc.Valid = nil, nil, nil, false
+    err = runOCertVector(sharedParams, seed, oc)
+    if err != nil {
+        return err
+    }
+    err = compareVector("OCert", vectors.OCert, oc)
+    if err != nil {
+        return err
+    }
+    if !oc.Valid {
+        return fmt.Errorf("OCert: the proof does not verify")
+    }
+    return nil
+}
+
+func compareVector(name string, expected interface{}, actual interface{}) error {
+    expectedBytes, err := json.Marshal(expected)
+    if err != nil {
+        return err
+    }
+    actualBytes, err := json.Marshal(actual)
+    if err != nil {
+        return err
+    }
+    if !bytes.Equal(expectedBytes, actualBytes) {
+        return fmt.Errorf("%s: outputs differ from the vector", name)
+    }
+    return nil
+}
+
+func runElGamalVector(sharedParams *SharedParams, seed []byte, v *ElGamalVector) {
+    rand := vectorRand(seed, "elgamal")
+    v.PKa, v.SKa = EKeyGen(sharedParams, rand)
+    v.P, v.EncProof = EEncWithProof(sharedParams, v.PKa, v.ID, rand)
+    v.PPrime, v.RPrime, v.RerandProof = ERerandWithProof(sharedParams, v.PKa, v.P, rand)
+}
+
+func runSPSVector(sharedParams *SharedParams, seed []byte, v *SPSVector) error {
+    scheme, err := GetSPSScheme(v.Scheme)
+    if err != nil {
+        return err
+    }
+    rand := vectorRand(seed, "sps-" + scheme.Name())
+    v.VK, v.SK = scheme.KeyGen(sharedParams, rand)
+    v.Ecert = scheme.Sign(sharedParams, v.SK, v.P, v.PKc, v.Epoch, rand)
+    return nil
+}
+
+func runGrothSahaiVector(sharedParams *SharedParams, seed []byte, v *GrothSahaiVector) {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    rand := vectorRand(seed, "groth-sahai")
+    alpha := randZr(pairing, rand)
+    v.Alpha = alpha.Bytes()
+    v.CRS, _ = CreateCommonReferenceString(sharedParams, alpha, rand).Bytes()
+}
+
+func runOCertVector(sharedParams *SharedParams, seed []byte, v *OCertVector) error {
+    pairing, _ := pbc.NewPairingFromString(sharedParams.Params)
+    rand := vectorRand(seed, "ocert")
+    v.PPrime, v.RPrime = ERerand(sharedParams, v.PKa, v.P, rand)
+
+    vars := new(ProofVariables)
+    vars.P = v.P
+    vars.PKc = v.PKc
+    vars.PKa = v.PKa
+    vars.E = v.Ecert
+    vars.VK = v.VK
+    vars.Xc = v.Xc
+    vars.RPrime = v.RPrime
+    pi := PSetup(sharedParams, vars, rand)
+    var err error
+    v.Pi, err = pi.Bytes()
+    if err != nil {
+        return err
+    }
+
+    // Verify the encoded proof, as GenOCert does
+    decoded := new(ProofOfKnowledge)
+    err = decoded.SetBytes(v.Pi)
+    if err != nil {
+        return err
+    }
+    G := pairing.NewG1().SetBytes(sharedParams.G1)
+    H := pairing.NewG2().SetBytes(sharedParams.G2)
+    consts := new(ProofConstants)
+    consts.VK = v.VK
+    consts.PPrime = v.PPrime
+    consts.PKa = v.PKa
+    consts.Egh = pairing.NewGT().Pair(G, H).Bytes()
+    consts.Egz = SPSEgz(sharedParams, v.VK)
+    consts.Epoch = v.Epoch.E
+    v.Valid = PProve(sharedParams, decoded, consts)
+    return nil
+}
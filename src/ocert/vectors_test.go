@@ -0,0 +1,85 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ocert
+
+import (
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+/*
+ * The fixture written by vector-tool/vectors.go
+ */
+var knownAnswerVectorsFile = filepath.Join("testdata", "vectors.json")
+
+/*
+ * Generated vectors survive encoding and replay, and a changed output is
+ * detected
+ */
+func TestVectorsRoundTrip(t *testing.T) {
+    sharedParams := GenerateSharedParams()
+    vectors, err := GenerateTestVectors(sharedParams, []byte("seed"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    value, err := vectors.Bytes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    decoded := new(TestVectors)
+    if err := decoded.SetBytes(value); err != nil {
+        t.Fatal(err)
+    }
+    if err := VerifyTestVectors(decoded); err != nil {
+        t.Fatal(err)
+    }
+
+    // Same inputs, different randomness
+    decoded.Seed = []byte("other")
+    if VerifyTestVectors(decoded) == nil {
+        t.Error("accepts the vectors under another seed")
+    }
+
+    decoded.Seed = vectors.Seed
+    decoded.SPS[1].Ecert.R = decoded.SPS[0].Ecert.R
+    if VerifyTestVectors(decoded) == nil {
+        t.Error("accepts a changed ecert")
+    }
+}
+
+/*
+ * Replay the committed fixture, if there is one
+ */
+func TestKnownAnswerVectors(t *testing.T) {
+    value, err := ioutil.ReadFile(knownAnswerVectorsFile)
+    if os.IsNotExist(err) {
+        t.Skip("no testdata/vectors.json")
+    }
+    if err != nil {
+        t.Fatal(err)
+    }
+    vectors := new(TestVectors)
+    if err := vectors.SetBytes(value); err != nil {
+        t.Fatal(err)
+    }
+    if err := VerifyTestVectors(vectors); err != nil {
+        t.Error(err)
+    }
+}
@@ -0,0 +1,103 @@
+/*
+ *
+ * Copyright 2017 Kewei Shi, Jeremy Hartmann, Tuhin Tiwari and Dharvi Verma
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+/*
+ * Generation and replay of the known-answer test vectors, see vectors.go.
+ *
+ *   go run vectors.go -out ../src/ocert/testdata/vectors.json
+ *   go run vectors.go -verify ../src/ocert/testdata/vectors.json
+ *
+ * The vectors are generated for fresh shared parameters, or for the
+ * result of the sharedParams chaincode function given by -params. -seed
+ * is the seed of the randomness of all vectors.
+ */
+
+package main
+
+import (
+    "flag"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "ocert"
+)
+
+func main() {
+    out := flag.String("out", "", "file to write the vectors to")
+    verify := flag.String("verify", "", "file of vectors to replay")
+    paramsFile := flag.String("params", "", "encoded SharedParams, generated if empty")
+    seed := flag.String("seed", "ocert-test-vectors", "seed of the randomness")
+    flag.Parse()
+
+    if *verify != "" {
+        value, err := ioutil.ReadFile(*verify)
+        if err != nil {
+            fmt.Println(err)
+            os.Exit(1)
+        }
+        vectors := new(ocert.TestVectors)
+        err = vectors.SetBytes(value)
+        if err != nil {
+            fmt.Println(err)
+            os.Exit(1)
+        }
+        err = ocert.VerifyTestVectors(vectors)
+        if err != nil {
+            fmt.Println(err)
+            os.Exit(1)
+        }
+        fmt.Println("All vectors verified")
+        return
+    }
+
+    if *out == "" {
+        flag.Usage()
+        os.Exit(2)
+    }
+    var sharedParams *ocert.SharedParams
+    if *paramsFile != "" {
+        value, err := ioutil.ReadFile(*paramsFile)
+        if err != nil {
+            fmt.Println(err)
+            os.Exit(1)
+        }
+        sharedParams = new(ocert.SharedParams)
+        err = sharedParams.SetBytes(value)
+        if err != nil {
+            fmt.Println(err)
+            os.Exit(1)
+        }
+    } else {
+        sharedParams = ocert.GenerateSharedParams()
+    }
+
+    vectors, err := ocert.GenerateTestVectors(sharedParams, []byte(*seed))
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+    value, err := vectors.Bytes()
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+    err = ioutil.WriteFile(*out, value, 0644)
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+}